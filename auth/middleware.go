@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const claimsContextKey = "auth_claims"
+
+// Middleware rejects requests without a valid bearer token and stores the
+// claims on the echo context. Browsers cannot set headers on WebSocket
+// handshakes, so upgrades may pass the token as ?token= instead.
+func Middleware(m *Manager) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token := requestToken(c)
+			if token == "" {
+				return c.JSON(http.StatusUnauthorized, map[string]any{
+					"success": false, "error": "missing bearer token",
+				})
+			}
+
+			claims, err := m.Parse(token)
+			if err != nil {
+				return c.JSON(http.StatusUnauthorized, map[string]any{
+					"success": false, "error": err.Error(),
+				})
+			}
+
+			c.Set(claimsContextKey, claims)
+			return next(c)
+		}
+	}
+}
+
+// Optional behaves like Middleware when a token is supplied and lets
+// anonymous requests through otherwise.
+func Optional(m *Manager) echo.MiddlewareFunc {
+	required := Middleware(m)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withAuth := required(next)
+		return func(c echo.Context) error {
+			if requestToken(c) == "" {
+				return next(c)
+			}
+			return withAuth(c)
+		}
+	}
+}
+
+// RequireRegistered must follow Middleware; it turns guest sessions away.
+func RequireRegistered(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if claims := ClaimsFrom(c); claims == nil || claims.Guest {
+			return c.JSON(http.StatusForbidden, map[string]any{
+				"success": false, "error": "a registered account is required",
+			})
+		}
+		return next(c)
+	}
+}
+
+// RequireAdmin must follow Middleware; it only lets catalog administrators
+// through.
+func RequireAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if claims := ClaimsFrom(c); claims == nil || !claims.Admin {
+			return c.JSON(http.StatusForbidden, map[string]any{
+				"success": false, "error": "admin access required",
+			})
+		}
+		return next(c)
+	}
+}
+
+func requestToken(c echo.Context) string {
+	token := bearerToken(c.Request().Header.Get(echo.HeaderAuthorization))
+	if token == "" && c.IsWebSocket() {
+		token = c.QueryParam("token")
+	}
+	return token
+}
+
+func bearerToken(header string) string {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// ClaimsFrom returns the claims stored by Middleware, or nil on public routes.
+func ClaimsFrom(c echo.Context) *Claims {
+	claims, _ := c.Get(claimsContextKey).(*Claims)
+	return claims
+}
+
+// UserID returns the authenticated user's ID, or uuid.Nil on public routes.
+func UserID(c echo.Context) uuid.UUID {
+	if claims := ClaimsFrom(c); claims != nil {
+		return claims.UserID()
+	}
+	return uuid.Nil
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// Claims are carried in every access token. The subject is the user ID.
+type Claims struct {
+	jwt.StandardClaims
+	Guest bool `json:"guest,omitempty"`
+	Admin bool `json:"admin,omitempty"`
+}
+
+// Manager issues and verifies HS256 access tokens.
+type Manager struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewManager(secret string, ttl time.Duration) *Manager {
+	return &Manager{secret: []byte(secret), ttl: ttl}
+}
+
+// Issue signs a token for userID and returns it with its expiry time.
+func (m *Manager) Issue(userID uuid.UUID, admin bool) (string, time.Time, error) {
+	expiresAt := time.Now().Add(m.ttl)
+	token, err := m.sign(Claims{StandardClaims: jwt.StandardClaims{Subject: userID.String()}, Admin: admin}, expiresAt)
+	return token, expiresAt, err
+}
+
+// IssueGuest signs a guest session token that expires together with the
+// guest account.
+func (m *Manager) IssueGuest(userID uuid.UUID, expiresAt time.Time) (string, error) {
+	return m.sign(Claims{StandardClaims: jwt.StandardClaims{Subject: userID.String()}, Guest: true}, expiresAt)
+}
+
+func (m *Manager) sign(claims Claims, expiresAt time.Time) (string, error) {
+	claims.IssuedAt = time.Now().Unix()
+	claims.ExpiresAt = expiresAt.Unix()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	if err != nil {
+		return "", fmt.Errorf("sign token: %w", err)
+	}
+	return token, nil
+}
+
+// Parse verifies the signature and expiry of token and returns its claims.
+func (m *Manager) Parse(token string) (*Claims, error) {
+	claims := &Claims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		return m.secret, nil
+	})
+	if err != nil || !parsed.Valid {
+		return nil, ErrInvalidToken
+	}
+	if _, err := uuid.Parse(claims.Subject); err != nil {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// UserID returns the user the claims were issued to.
+func (c *Claims) UserID() uuid.UUID {
+	id, _ := uuid.Parse(c.Subject)
+	return id
+}
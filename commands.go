@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"backend/config"
+	"backend/database"
+)
+
+const migrateUsage = "usage: main migrate up|down [steps]|status"
+
+// runCommand handles CLI subcommands. It reports false when args do not name
+// one, in which case the HTTP server should start.
+func runCommand(cfg *config.Config, args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+
+	switch args[0] {
+	case "migrate":
+		return true, runMigrate(cfg, args[1:])
+	case "seed":
+		return true, runSeed(cfg)
+	default:
+		return true, fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	db, err := database.NewConnection(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		return db.MigrateUp(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid steps %q", args[1])
+			}
+		}
+		return db.MigrateDown(ctx, steps)
+	case "status":
+		statuses, err := db.MigrationStatus(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, st := range statuses {
+			applied := "pending"
+			if st.AppliedAt != nil {
+				applied = st.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", st.Version, st.Name, applied)
+		}
+		return w.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+func runSeed(cfg *config.Config) error {
+	if cfg.IsProduction() {
+		return errors.New("refusing to seed a production database")
+	}
+
+	db, err := database.NewConnection(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.MigrateUp(ctx); err != nil {
+		return err
+	}
+	return db.Seed(ctx)
+}
@@ -1,9 +1,15 @@
 package config
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -11,6 +17,8 @@ import (
 type Config struct {
 	Database DatabaseConfig
 	Server   ServerConfig
+	LLM      LLMConfig
+	Auth     AuthConfig
 	Env      string
 }
 
@@ -21,13 +29,142 @@ type DatabaseConfig struct {
 	Password string
 	Name     string
 	SSLMode  string
+
+	AutoMigrate bool // apply pending migrations on startup
+	Seed        bool // load development sample data on startup (ignored in production)
 }
 
 type ServerConfig struct {
 	Host string
 	Port int
+
+	// AllowedOrigins are the browser origins ("scheme://host[:port]") allowed
+	// to open WebSocket connections besides the API's own; "*" allows any.
+	AllowedOrigins []string
+}
+
+type AuthConfig struct {
+	JWTSecret string
+	TokenTTL  time.Duration
+
+	GuestTTL             time.Duration // unclaimed guest chats are deleted after this
+	GuestCleanupInterval time.Duration
+}
+
+type LLMConfig struct {
+	Provider string // "openai" or "fake"
+	BaseURL  string
+	APIKey   string
+	Timeout  time.Duration // per non-streaming call
+
+	// Sampling settings sent with every request; nil and 0 leave the
+	// provider's defaults.
+	Temperature *float64
+	MaxTokens   int
+
+	// Fallbacks is the chain of provider/model pairs tried in order until one
+	// answers; a pair without a model keeps the chat's model. Providers other
+	// than "openai" (BaseURL and APIKey above) and "fake" are looked up in
+	// Endpoints.
+	Fallbacks []LLMTarget
+	Endpoints map[string]LLMEndpoint
+
+	// Models is the registry of models chats may use; a chat's model is
+	// tried on its registered provider before the fallback chain.
+	DefaultModel string
+	Models       []ModelSpec
+
+	// Prompt token budget per request. ContextBudgets overrides the default
+	// for individual model names as stored in chats.model.
+	ContextBudget  int
+	ContextBudgets map[string]int
+
+	// Rolling summaries: once SummaryBatch messages older than the newest
+	// SummaryKeepRecent are not yet summarized, they are folded into the
+	// chat summary.
+	SummaryEnabled    bool
+	SummaryKeepRecent int
+	SummaryBatch      int
+
+	// Upper bound on model round trips per turn while it keeps calling
+	// tools; the last round must answer in text.
+	MaxToolIterations int
+
+	// Rate-limited and overloaded calls are retried with exponential backoff.
+	// After BreakerThreshold consecutive provider failures calls fail fast
+	// for BreakerCooldown (0 disables the breaker).
+	MaxRetries       int
+	RetryBaseDelay   time.Duration
+	RetryMaxDelay    time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+type LLMTarget struct {
+	Provider string
+	Model    string
+}
+
+type LLMEndpoint struct {
+	BaseURL string
+	APIKey  string
+}
+
+// ModelSpec is a model registry entry. Prices are in USD per million tokens.
+type ModelSpec struct {
+	Name            string  `json:"name"`
+	Provider        string  `json:"provider"`
+	ContextWindow   int     `json:"context_window"` // tokens, prompt and reply together
+	PromptPrice     float64 `json:"prompt_price"`
+	CompletionPrice float64 `json:"completion_price"`
+	Tools           bool    `json:"tools"`     // supports function calling
+	JSONMode        bool    `json:"json_mode"` // supports json_schema response formats
 }
 
+// defaultModels is the built-in registry, served by the primary provider.
+var defaultModels = []ModelSpec{
+	{Name: "gpt-4o-mini", ContextWindow: 128000, PromptPrice: 0.15, CompletionPrice: 0.6, Tools: true, JSONMode: true},
+	{Name: "gpt-4o", ContextWindow: 128000, PromptPrice: 2.5, CompletionPrice: 10, Tools: true, JSONMode: true},
+}
+
+// Model looks name up in the registry.
+func (c LLMConfig) Model(name string) (ModelSpec, bool) {
+	for _, m := range c.Models {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return ModelSpec{}, false
+}
+
+// defaultReplyReserve is the room kept for the reply in the context window
+// when LLM_MAX_TOKENS leaves its length to the provider.
+const defaultReplyReserve = 4096
+
+// ContextBudgetFor returns the prompt token budget for model: its override,
+// or the default budget capped by what the model's context window leaves
+// after the reply.
+func (c LLMConfig) ContextBudgetFor(model string) int {
+	if budget, ok := c.ContextBudgets[model]; ok {
+		return budget
+	}
+	if spec, ok := c.Model(model); ok && spec.ContextWindow > 0 {
+		reply := c.MaxTokens
+		if reply == 0 {
+			reply = defaultReplyReserve
+		}
+		return max(min(c.ContextBudget, spec.ContextWindow-reply), 1)
+	}
+	return c.ContextBudget
+}
+
+// Default secrets, good enough for local development and refused in
+// production.
+const (
+	defaultDBPassword = "password"
+	defaultJWTSecret  = "dev-secret-change-me"
+)
+
 func Load() (*Config, error) {
 	// Load .env file if it exists
 	if err := godotenv.Load(); err != nil {
@@ -35,35 +172,149 @@ func Load() (*Config, error) {
 		fmt.Println("No .env file found, using environment variables")
 	}
 
-	port, err := strconv.Atoi(getEnv("DB_PORT", "5432"))
-	if err != nil {
-		return nil, fmt.Errorf("invalid DB_PORT: %w", err)
-	}
+	l := &loader{}
+	provider := l.str("LLM_PROVIDER", "openai")
+
+	fallbacks, err := parseTargets(l.str("LLM_FALLBACKS", provider))
+	l.parsed("LLM_FALLBACKS", err)
+
+	registry, err := parseModels(os.Getenv("LLM_MODELS"), provider)
+	l.parsed("LLM_MODELS", err)
 
-	serverPort, err := strconv.Atoi(getEnv("SERVER_PORT", "8080"))
-	if err != nil {
-		return nil, fmt.Errorf("invalid SERVER_PORT: %w", err)
+	defaultModel := os.Getenv("LLM_DEFAULT_MODEL")
+	if defaultModel == "" && len(registry) > 0 {
+		defaultModel = registry[0].Name
 	}
+	if !slices.ContainsFunc(registry, func(m ModelSpec) bool { return m.Name == defaultModel }) {
+		l.fail("invalid LLM_DEFAULT_MODEL: %q is not in the model registry", defaultModel)
+	}
+
+	contextBudgets, err := parseIntMap(l.str("LLM_CONTEXT_BUDGETS", ""))
+	l.parsed("LLM_CONTEXT_BUDGETS", err)
+
+	origins, err := parseOrigins(l.str("WS_ALLOWED_ORIGINS", ""))
+	l.parsed("WS_ALLOWED_ORIGINS", err)
 
 	config := &Config{
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     port,
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", "password"),
-			Name:     getEnv("DB_NAME", "hackathon_db"),
-			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
+			Host:     l.str("DB_HOST", "localhost"),
+			Port:     l.int("DB_PORT", 5432, 1),
+			User:     l.str("DB_USER", "postgres"),
+			Password: l.secret("DB_PASSWORD", defaultDBPassword),
+			Name:     l.str("DB_NAME", "hackathon_db"),
+			SSLMode:  l.str("DB_SSL_MODE", "disable"),
+
+			AutoMigrate: l.bool("DB_AUTO_MIGRATE", true),
+			Seed:        l.bool("DB_SEED", false),
 		},
 		Server: ServerConfig{
-			Host: getEnv("SERVER_HOST", "localhost"),
-			Port: serverPort,
+			Host: l.str("SERVER_HOST", "localhost"),
+			Port: l.int("SERVER_PORT", 8080, 1),
+
+			AllowedOrigins: origins,
 		},
-		Env: getEnv("ENV", "development"),
+		LLM: LLMConfig{
+			Provider:    provider,
+			BaseURL:     l.str("LLM_BASE_URL", "https://api.openai.com/v1"),
+			APIKey:      l.secret("LLM_API_KEY", ""),
+			Timeout:     l.duration("LLM_TIMEOUT", "30s", time.Second),
+			Temperature: l.optionalFloat("LLM_TEMPERATURE", 0, 2),
+			MaxTokens:   l.int("LLM_MAX_TOKENS", 0, 0),
+
+			Fallbacks: fallbacks,
+			Endpoints: parseEndpoints(l, os.Environ()),
+
+			DefaultModel: defaultModel,
+			Models:       registry,
+
+			ContextBudget:  l.int("LLM_CONTEXT_BUDGET", 16000, 1),
+			ContextBudgets: contextBudgets,
+
+			SummaryEnabled:    l.bool("LLM_SUMMARY_ENABLED", true),
+			SummaryKeepRecent: l.int("LLM_SUMMARY_KEEP_RECENT", 6, 0),
+			SummaryBatch:      l.int("LLM_SUMMARY_BATCH", 10, 1),
+
+			MaxToolIterations: l.int("LLM_MAX_TOOL_ITERATIONS", 5, 1),
+
+			MaxRetries:       l.int("LLM_MAX_RETRIES", 2, 0),
+			RetryBaseDelay:   l.duration("LLM_RETRY_BASE_DELAY", "500ms", 0),
+			RetryMaxDelay:    l.duration("LLM_RETRY_MAX_DELAY", "10s", 0),
+			BreakerThreshold: l.int("LLM_BREAKER_THRESHOLD", 5, 0),
+			BreakerCooldown:  l.duration("LLM_BREAKER_COOLDOWN", "30s", 0),
+		},
+		Auth: AuthConfig{
+			JWTSecret: l.secret("JWT_SECRET", defaultJWTSecret),
+			TokenTTL:  l.duration("JWT_TTL", "24h", time.Minute),
+
+			GuestTTL:             l.duration("GUEST_TTL", "168h", time.Minute),
+			GuestCleanupInterval: l.duration("GUEST_CLEANUP_INTERVAL", "1h", time.Second),
+		},
+		Env: l.str("ENV", "development"),
 	}
 
+	if config.IsProduction() {
+		l.problems = append(l.problems, config.productionProblems()...)
+	}
+	if len(l.problems) > 0 {
+		return nil, errors.Join(l.problems...)
+	}
 	return config, nil
 }
 
+// productionProblems lists settings that are fine for development but must
+// not reach production.
+func (c *Config) productionProblems() []error {
+	var problems []error
+	fail := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	if c.Database.Password == "" || c.Database.Password == defaultDBPassword {
+		fail("DB_PASSWORD is missing or left at its default")
+	}
+	switch {
+	case c.Auth.JWTSecret == defaultJWTSecret:
+		fail("JWT_SECRET is left at its default")
+	case len(c.Auth.JWTSecret) < 32:
+		fail("JWT_SECRET must be at least 32 characters")
+	}
+	if slices.Contains(c.Server.AllowedOrigins, "*") {
+		fail("WS_ALLOWED_ORIGINS must list origins, not \"*\"")
+	}
+
+	used := make(map[string]bool)
+	for _, t := range c.LLM.Fallbacks {
+		used[t.Provider] = true
+	}
+	for _, m := range c.LLM.Models {
+		used[m.Provider] = true
+	}
+	for name := range used {
+		switch name {
+		case "fake":
+			fail("LLM provider %q is for development only", name)
+		case "openai":
+			if c.LLM.APIKey == "" {
+				fail("LLM_API_KEY is missing")
+			}
+		default:
+			e, ok := c.LLM.Endpoints[name]
+			if !ok || e.BaseURL == "" {
+				fail("LLM_ENDPOINT_%s_BASE_URL is missing", strings.ToUpper(name))
+			}
+			if e.APIKey == "" {
+				fail("LLM_ENDPOINT_%s_API_KEY is missing", strings.ToUpper(name))
+			}
+		}
+	}
+	slices.SortFunc(problems, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+	return problems
+}
+
+func (c *Config) IsProduction() bool {
+	return c.Env == "production"
+}
+
 func (c *Config) DatabaseURL() string {
 	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
 		c.Database.User,
@@ -79,9 +330,119 @@ func (c *Config) ServerAddress() string {
 	return fmt.Sprintf("%s:%d", c.Server.Host, c.Server.Port)
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// parseIntMap parses "key=value,key=value" into a map.
+func parseIntMap(s string) (map[string]int, error) {
+	out := make(map[string]int)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected key=value, got %q", pair)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		out[strings.TrimSpace(key)] = n
+	}
+	return out, nil
+}
+
+// parseTargets parses "provider:model,provider,..." into fallback targets.
+func parseTargets(s string) ([]LLMTarget, error) {
+	var out []LLMTarget
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		provider, model, _ := strings.Cut(item, ":")
+		provider = strings.TrimSpace(provider)
+		if provider == "" {
+			return nil, fmt.Errorf("missing provider in %q", item)
+		}
+		out = append(out, LLMTarget{Provider: provider, Model: strings.TrimSpace(model)})
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no providers listed")
+	}
+	return out, nil
+}
+
+// parseOrigins parses a comma-separated list of "scheme://host[:port]"
+// origins, lowercased so they compare with Origin headers as-is.
+func parseOrigins(s string) ([]string, error) {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		item = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(item), "/"))
+		if item == "" {
+			continue
+		}
+		if item != "*" {
+			u, err := url.Parse(item)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+				return nil, fmt.Errorf("%q is not an origin like https://app.example.com", item)
+			}
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// parseEndpoints collects LLM_ENDPOINT_<NAME>_BASE_URL and _API_KEY (or
+// _API_KEY_FILE) variables into endpoints keyed by the lowercased name.
+func parseEndpoints(l *loader, environ []string) map[string]LLMEndpoint {
+	out := make(map[string]LLMEndpoint)
+	keys := make(map[string]bool) // names whose key was read, from either variable
+	for _, kv := range environ {
+		key, value, _ := strings.Cut(kv, "=")
+		rest, ok := strings.CutPrefix(key, "LLM_ENDPOINT_")
+		if !ok {
+			continue
+		}
+		if name, ok := strings.CutSuffix(rest, "_BASE_URL"); ok && name != "" {
+			e := out[strings.ToLower(name)]
+			e.BaseURL = value
+			out[strings.ToLower(name)] = e
+		} else if name, ok := strings.CutSuffix(strings.TrimSuffix(rest, "_FILE"), "_API_KEY"); ok && name != "" && !keys[name] {
+			keys[name] = true
+			e := out[strings.ToLower(name)]
+			e.APIKey = l.secret("LLM_ENDPOINT_"+name+"_API_KEY", "")
+			out[strings.ToLower(name)] = e
+		}
+	}
+	return out
+}
+
+// parseModels reads the model registry from a JSON array of ModelSpec; empty
+// means the built-in registry on the primary provider.
+func parseModels(s, primary string) ([]ModelSpec, error) {
+	if strings.TrimSpace(s) == "" {
+		out := slices.Clone(defaultModels)
+		for i := range out {
+			out[i].Provider = primary
+		}
+		return out, nil
+	}
+	var out []ModelSpec
+	if err := json.Unmarshal([]byte(s), &out); err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no models listed")
+	}
+	seen := make(map[string]bool)
+	for _, m := range out {
+		if m.Name == "" || m.Provider == "" {
+			return nil, fmt.Errorf("every model needs a name and a provider")
+		}
+		if seen[m.Name] {
+			return nil, fmt.Errorf("duplicate model %q", m.Name)
+		}
+		seen[m.Name] = true
 	}
-	return defaultValue
+	return out, nil
 }
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseOrigins(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"http://localhost:3000, https://App.example.com/", []string{"http://localhost:3000", "https://app.example.com"}, false},
+		{"*", []string{"*"}, false},
+		{"localhost:3000", nil, true},
+		{"https://app.example.com/chat", nil, true},
+		{"ftp://example.com", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseOrigins(tt.in)
+		if (err != nil) != tt.wantErr || !slices.Equal(got, tt.want) {
+			t.Errorf("parseOrigins(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestDurationMinimum(t *testing.T) {
+	tests := []struct {
+		raw     string
+		wantErr bool
+	}{
+		{"45s", false},
+		{"1s", false},
+		{"0", true},
+		{"500ms", true},
+		{"-1s", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("LLM_TIMEOUT", tt.raw)
+		l := &loader{}
+		l.duration("LLM_TIMEOUT", "30s", time.Second)
+		if got := len(l.problems) > 0; got != tt.wantErr {
+			t.Errorf("LLM_TIMEOUT=%s: problems %v, want error %v", tt.raw, l.problems, tt.wantErr)
+		}
+	}
+}
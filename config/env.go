@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// loader reads typed settings from the environment, collecting every problem
+// instead of stopping at the first so a broken deployment is fixed in one go.
+type loader struct {
+	problems []error
+}
+
+func (l *loader) fail(format string, args ...any) {
+	l.problems = append(l.problems, fmt.Errorf(format, args...))
+}
+
+func (l *loader) str(key, def string) string {
+	return getEnv(key, def)
+}
+
+// secret reads key from the file named by KEY_FILE if that is set (Docker
+// and Kubernetes secrets), from KEY otherwise.
+func (l *loader) secret(key, def string) string {
+	path := os.Getenv(key + "_FILE")
+	if path == "" {
+		return getEnv(key, def)
+	}
+	if os.Getenv(key) != "" {
+		l.fail("%s and %s_FILE are both set", key, key)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		l.fail("%s_FILE: %v", key, err)
+		return def
+	}
+	return strings.TrimRight(string(data), "\r\n")
+}
+
+// int reads an integer of at least minValue.
+func (l *loader) int(key string, def, minValue int) int {
+	raw := getEnv(key, strconv.Itoa(def))
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < minValue {
+		l.fail("invalid %s: %q, want an integer >= %d", key, raw, minValue)
+		return def
+	}
+	return n
+}
+
+func (l *loader) bool(key string, def bool) bool {
+	raw := getEnv(key, strconv.FormatBool(def))
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		l.fail("invalid %s: %q, want true or false", key, raw)
+		return def
+	}
+	return b
+}
+
+// duration reads a duration of at least minValue.
+func (l *loader) duration(key, def string, minValue time.Duration) time.Duration {
+	raw := getEnv(key, def)
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < minValue {
+		l.fail("invalid %s: %q, want a duration like %s, at least %s", key, raw, def, minValue)
+		d, _ = time.ParseDuration(def)
+	}
+	return d
+}
+
+// optionalFloat reads a float in [minValue, maxValue], nil if key is unset.
+func (l *loader) optionalFloat(key string, minValue, maxValue float64) *float64 {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil || f < minValue || f > maxValue {
+		l.fail("invalid %s: %q, want a number between %g and %g", key, raw, minValue, maxValue)
+		return nil
+	}
+	return &f
+}
+
+// parsed records the error of a custom parser under key.
+func (l *loader) parsed(key string, err error) {
+	if err != nil {
+		l.fail("invalid %s: %v", key, err)
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,19 @@
+-- Baseline schema. Written to be idempotent so databases bootstrapped by the
+-- old scripts/init.sql can adopt migrations without manual steps.
+CREATE TABLE IF NOT EXISTS chats (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title      TEXT,
+    model      TEXT NOT NULL,                  -- e.g. "gpt-4o-mini"
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS messages (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    chat_id    UUID NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+    role       VARCHAR(32) NOT NULL,           -- 'user' | 'assistant' | 'system'
+    content    TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Superseded by schema_migrations.
+DROP TABLE IF EXISTS app_bootstrap;
@@ -0,0 +1,2 @@
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'completed'; -- 'completed' | 'cancelled' | 'failed'
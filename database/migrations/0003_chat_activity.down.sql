@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS chats_last_message_at_idx;
+
+ALTER TABLE chats
+    DROP COLUMN IF EXISTS archived_at,
+    DROP COLUMN IF EXISTS last_message_at,
+    DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,12 @@
+ALTER TABLE chats
+    ADD COLUMN IF NOT EXISTS updated_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS last_message_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS archived_at     TIMESTAMPTZ;
+
+UPDATE chats c
+SET last_message_at = COALESCE(
+        (SELECT max(m.created_at) FROM messages m WHERE m.chat_id = c.id),
+        c.created_at),
+    updated_at = c.created_at;
+
+CREATE INDEX IF NOT EXISTS chats_last_message_at_idx ON chats (last_message_at DESC, id DESC);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS chats_user_last_message_at_idx;
+ALTER TABLE chats DROP COLUMN IF EXISTS user_id;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,10 @@
+DELETE FROM users WHERE is_guest;
+
+DROP INDEX IF EXISTS users_guest_expires_at_idx;
+
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS users_credentials_check,
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS is_guest,
+    ALTER COLUMN password_hash SET NOT NULL,
+    ALTER COLUMN email SET NOT NULL;
@@ -0,0 +1,12 @@
+-- Guests are users without credentials that expire unless claimed.
+ALTER TABLE users
+    ALTER COLUMN email DROP NOT NULL,
+    ALTER COLUMN password_hash DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS is_guest   BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+ALTER TABLE users
+    ADD CONSTRAINT users_credentials_check
+    CHECK (is_guest OR (email IS NOT NULL AND password_hash IS NOT NULL));
+
+CREATE INDEX IF NOT EXISTS users_guest_expires_at_idx ON users (expires_at) WHERE is_guest;
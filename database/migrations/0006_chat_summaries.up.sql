@@ -0,0 +1,13 @@
+-- Rolling summaries of older turns. Each row covers a prefix of the chat;
+-- message_ids lists exactly which messages it replaces so it can be detected
+-- as stale when any of them disappears.
+CREATE TABLE IF NOT EXISTS chat_summaries (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    chat_id     UUID NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+    content     TEXT NOT NULL,
+    message_ids UUID[] NOT NULL,
+    model       TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS chat_summaries_chat_created_idx ON chat_summaries (chat_id, created_at DESC);
@@ -0,0 +1,68 @@
+CREATE TABLE IF NOT EXISTS products (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code           TEXT NOT NULL UNIQUE,
+    name           TEXT NOT NULL,
+    segment        TEXT NOT NULL CHECK (segment IN ('retail', 'business')),
+    description    TEXT NOT NULL DEFAULT '',
+    min_amount     BIGINT CHECK (min_amount >= 0),           -- tenge
+    max_amount     BIGINT CHECK (max_amount >= 0),
+    min_term       INT CHECK (min_term >= 0),
+    max_term       INT CHECK (max_term >= 0),
+    term_unit      TEXT NOT NULL DEFAULT 'month' CHECK (term_unit IN ('day', 'month')),
+    min_age        INT,
+    max_age        INT,
+    expected_yield NUMERIC(5, 2),                            -- % per year, expected, not guaranteed
+    fees           TEXT NOT NULL DEFAULT '',
+    active         BOOLEAN NOT NULL DEFAULT true,
+    sort_order     INT NOT NULL DEFAULT 0,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (min_amount IS NULL OR max_amount IS NULL OR min_amount <= max_amount),
+    CHECK (min_term IS NULL OR max_term IS NULL OR min_term <= max_term),
+    CHECK (min_age IS NULL OR max_age IS NULL OR min_age <= max_age)
+);
+
+-- Catalog as previously described in the system prompt.
+INSERT INTO products (code, name, segment, description, min_amount, max_amount, min_term, max_term, term_unit, min_age, max_age, expected_yield, fees, sort_order) VALUES
+    ('bnpl', 'BNPL (рассрочка)', 'retail',
+     'Подходит для небольших покупок, когда хочется распределить платежи.',
+     10000, 300000, 1, 12, 'month', 18, 63, NULL, '', 10),
+    ('islamic_financing', 'Исламское финансирование', 'retail',
+     'Для средних целей/покупок, соответствие шариату.',
+     100000, 5000000, 3, 60, 'month', 18, 60, NULL, '', 20),
+    ('islamic_mortgage', 'Исламская ипотека', 'retail',
+     'Для жилья, большой горизонт, важна «подушка».',
+     3000000, 75000000, 12, 240, 'month', 25, 60, NULL, '', 30),
+    ('kopilka', 'Копилка', 'retail',
+     'Инвест-продукт. Гибкие пополнения, удобна для «подушки» и коротких целей.',
+     1000, 20000000, 1, 12, 'month', NULL, NULL, 18.00, '', 40),
+    ('vakala', 'Вакала', 'retail',
+     'Инвест-продукт для среднесрочных накоплений, часть «подушки» туда лучше не переносить.',
+     50000, NULL, 3, 36, 'month', NULL, NULL, 20.00, '', 50),
+    ('business_overdraft', 'Бизнес-карта с исламским кредитным лимитом (овердрафт)', 'business',
+     'Для кассовых разрывов и короткой ликвидности.',
+     NULL, 10000000, NULL, 30, 'day', 21, 63, NULL, '', 60),
+    ('business_unsecured', 'Исламский беззалоговый кредит', 'business',
+     'Когда залога нет, нужен оборотный капитал.',
+     100000, 10000000, 3, 60, 'month', 21, 63, NULL, '', 70),
+    ('business_secured', 'Исламский залоговый кредит', 'business',
+     'Для оборудования/инвест-проектов при наличии залога.',
+     100000, 10000000, 3, 60, 'month', 21, 63, NULL, '', 80),
+    ('deposit_overnight', 'Депозит «Овернайт»', 'business',
+     'Для парковки ликвидности на короткий срок.',
+     NULL, 100000000, 1, 12, 'month', NULL, NULL, 12.00, '', 90),
+    ('deposit_vygodny', 'Депозит «Выгодный»', 'business',
+     'Для доходности на срок.',
+     NULL, 100000000, 3, 12, 'month', NULL, NULL, 17.00, '', 100),
+    ('business_card', 'Бизнес-карта (платёжная)', 'business',
+     'Для повседневных бизнес-расходов, кешбэк до 1% по бизнес-расходам.',
+     NULL, NULL, NULL, NULL, 'month', NULL, NULL, NULL,
+     'Выпуск/обслуживание 0 ₸, снятие наличных: до 1 млн ₸ — бесплатно, свыше — 1%.', 110),
+    ('rko_packages', 'РКО-пакеты', 'business',
+     'От 10 до 200 платежей в месяц; бонусы и сервисы для бизнеса.',
+     NULL, NULL, NULL, NULL, 'month', NULL, NULL, NULL,
+     'Абонплата 0–15 000 ₸/мес, открытие счёта бесплатно.', 120)
+ON CONFLICT (code) DO NOTHING;
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT false;
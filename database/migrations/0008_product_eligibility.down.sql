@@ -0,0 +1,3 @@
+ALTER TABLE products
+    DROP COLUMN IF EXISTS requires_collateral,
+    DROP COLUMN IF EXISTS purposes;
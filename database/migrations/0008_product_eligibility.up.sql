@@ -0,0 +1,20 @@
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS purposes            TEXT[] NOT NULL DEFAULT '{}', -- empty means any purpose
+    ADD COLUMN IF NOT EXISTS requires_collateral BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE products SET purposes = v.purposes, requires_collateral = v.requires_collateral
+FROM (VALUES
+    ('bnpl',               '{purchase}'::text[],                false),
+    ('islamic_financing',  '{purchase}'::text[],                false),
+    ('islamic_mortgage',   '{housing}'::text[],                 false),
+    ('kopilka',            '{cushion,savings}'::text[],         false),
+    ('vakala',             '{savings}'::text[],                 false),
+    ('business_overdraft', '{liquidity}'::text[],               false),
+    ('business_unsecured', '{working_capital}'::text[],         false),
+    ('business_secured',   '{equipment,investment}'::text[],    true),
+    ('deposit_overnight',  '{placement}'::text[],               false),
+    ('deposit_vygodny',    '{placement}'::text[],               false),
+    ('business_card',      '{payments}'::text[],                false),
+    ('rko_packages',       '{payments}'::text[],                false)
+) AS v(code, purposes, requires_collateral)
+WHERE products.code = v.code;
@@ -0,0 +1,5 @@
+DELETE FROM messages WHERE role = 'tool' OR tool_calls IS NOT NULL;
+
+ALTER TABLE messages
+    DROP COLUMN IF EXISTS tool_call_id,
+    DROP COLUMN IF EXISTS tool_calls;
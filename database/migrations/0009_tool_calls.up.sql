@@ -0,0 +1,5 @@
+-- Tool use within a turn is stored as ordinary messages: an assistant message
+-- with tool_calls, then one 'tool' message per call carrying its result.
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS tool_calls   JSONB, -- [{"id", "name", "arguments"}] on assistant messages
+    ADD COLUMN IF NOT EXISTS tool_call_id TEXT;  -- on 'tool' messages
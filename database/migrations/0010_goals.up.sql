@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS goals (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id       UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type          TEXT NOT NULL CHECK (type IN ('cushion', 'purchase', 'housing', 'business_liquidity')),
+    title         TEXT NOT NULL,
+    target_amount BIGINT NOT NULL CHECK (target_amount > 0), -- tenge
+    saved_amount  BIGINT NOT NULL DEFAULT 0 CHECK (saved_amount >= 0),
+    deadline      DATE,
+    product_id    UUID REFERENCES products(id) ON DELETE SET NULL,
+    status        TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'achieved', 'archived')),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS goals_user_status_idx ON goals (user_id, status);
+
+-- Micro-actions: small steps towards a goal. Completing one adds its amount
+-- to the goal's saved_amount.
+CREATE TABLE IF NOT EXISTS goal_actions (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    goal_id      UUID NOT NULL REFERENCES goals(id) ON DELETE CASCADE,
+    description  TEXT NOT NULL,
+    amount       BIGINT NOT NULL DEFAULT 0 CHECK (amount >= 0),
+    due_date     DATE,
+    depends_on   UUID REFERENCES goal_actions(id) ON DELETE SET NULL,
+    status       TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'done')),
+    completed_at TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS goal_actions_goal_idx ON goal_actions (goal_id, created_at);
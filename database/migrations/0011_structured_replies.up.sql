@@ -0,0 +1,2 @@
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS structured JSONB; -- parsed reply of structured mode, content keeps the raw JSON
@@ -0,0 +1,3 @@
+ALTER TABLE messages
+    DROP COLUMN IF EXISTS suggestion_id,
+    DROP COLUMN IF EXISTS suggestions;
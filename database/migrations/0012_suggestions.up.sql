@@ -0,0 +1,3 @@
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS suggestions   JSONB, -- [{"id", "text"}] quick replies offered by an assistant message
+    ADD COLUMN IF NOT EXISTS suggestion_id UUID;  -- on user messages sent by tapping one of them
@@ -0,0 +1,2 @@
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS model TEXT; -- model that produced an assistant message, may differ from chats.model after a fallback
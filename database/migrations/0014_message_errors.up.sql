@@ -0,0 +1,4 @@
+-- Assistant messages are now created as 'pending' before the model is called
+-- and finalized as 'completed', 'failed' or 'cancelled'.
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS error TEXT; -- stable error code of a failed or cancelled reply
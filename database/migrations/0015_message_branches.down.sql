@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS messages_parent_id_idx;
+
+ALTER TABLE chats
+    DROP COLUMN IF EXISTS active_leaf_id;
+
+ALTER TABLE messages
+    DROP COLUMN IF EXISTS parent_id;
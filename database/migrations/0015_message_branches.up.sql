@@ -0,0 +1,27 @@
+-- Messages form a tree: regenerating a reply or editing a question adds a
+-- sibling instead of replacing it. A chat shows and continues the branch that
+-- ends at its active leaf.
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES messages(id) ON DELETE SET NULL;
+
+-- Not a foreign key: it would make deleting a chat fight with the cascade
+-- from chats to messages.
+ALTER TABLE chats
+    ADD COLUMN IF NOT EXISTS active_leaf_id UUID;
+
+-- Existing chats are a single branch in creation order. Chats with a leaf
+-- were already converted, which keeps this safe to run twice.
+UPDATE messages m
+SET parent_id = p.prev_id
+FROM (
+    SELECT id, lag(id) OVER (PARTITION BY chat_id ORDER BY created_at, id) AS prev_id
+    FROM messages
+) p, chats c
+WHERE m.id = p.id AND c.id = m.chat_id AND c.active_leaf_id IS NULL AND p.prev_id IS NOT NULL;
+
+UPDATE chats c
+SET active_leaf_id = (
+    SELECT m.id FROM messages m WHERE m.chat_id = c.id ORDER BY m.created_at DESC, m.id DESC LIMIT 1)
+WHERE c.active_leaf_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS messages_parent_id_idx ON messages (parent_id);
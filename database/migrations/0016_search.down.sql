@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS chats_search_idx;
+DROP INDEX IF EXISTS messages_search_idx;
+
+ALTER TABLE chats
+    DROP COLUMN IF EXISTS search;
+
+ALTER TABLE messages
+    DROP COLUMN IF EXISTS search;
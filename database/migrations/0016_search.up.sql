@@ -0,0 +1,17 @@
+-- Full-text search over the user's side of chats. Russian and English get
+-- their stemmers; PostgreSQL ships none for Kazakh, so the 'simple'
+-- configuration matches Kazakh (and anything else) by exact word form.
+-- System prompts and tool steps get no vector and so can never match.
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS search tsvector GENERATED ALWAYS AS (
+        CASE WHEN role IN ('user', 'assistant') AND tool_calls IS NULL THEN
+            to_tsvector('russian', content) || to_tsvector('english', content) || to_tsvector('simple', content)
+        END) STORED;
+
+ALTER TABLE chats
+    ADD COLUMN IF NOT EXISTS search tsvector GENERATED ALWAYS AS (
+        to_tsvector('russian', COALESCE(title, '')) || to_tsvector('english', COALESCE(title, ''))
+            || to_tsvector('simple', COALESCE(title, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS messages_search_idx ON messages USING gin (search);
+CREATE INDEX IF NOT EXISTS chats_search_idx ON chats USING gin (search);
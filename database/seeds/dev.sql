@@ -0,0 +1,30 @@
+-- Sample data for local development. Never run against production.
+-- Log in as dev@example.com / password123 (an admin).
+INSERT INTO users (id, email, name, password_hash, is_admin) VALUES
+    ('5b1f6f3e-8c1e-4b8e-9a51-2f0c3d1e7a10', 'dev@example.com', 'Dev User',
+     '$2a$10$/7YniuwGltPOBxiFduIdMeyre.3rXvowb90rJrQ2KCyqqPymZGRwi', TRUE)
+ON CONFLICT DO NOTHING;
+
+INSERT INTO chats (id, user_id, title, model) VALUES
+    ('1719e433-4215-4450-9a72-ae2ec5956224', '5b1f6f3e-8c1e-4b8e-9a51-2f0c3d1e7a10', 'Sample chat', 'gpt-4o-mini')
+ON CONFLICT (id) DO NOTHING;
+
+-- One branch under a system root, the way CreateNewChat stores a chat.
+INSERT INTO messages (id, chat_id, parent_id, role, content, created_at)
+SELECT v.id::uuid, v.chat_id::uuid, v.parent_id::uuid, v.role, v.content, now() + v.seq * interval '1 second'
+FROM (VALUES
+    (0, 'b0d6c1a2-3f4e-4a5b-8c6d-7e8f9a0b1c20', '1719e433-4215-4450-9a72-ae2ec5956224', NULL, 'system',
+     'You are a helpful financial coach.'),
+    (1, 'b0d6c1a2-3f4e-4a5b-8c6d-7e8f9a0b1c21', '1719e433-4215-4450-9a72-ae2ec5956224', 'b0d6c1a2-3f4e-4a5b-8c6d-7e8f9a0b1c20', 'user',
+     'Hello, how are you?'),
+    (2, 'b0d6c1a2-3f4e-4a5b-8c6d-7e8f9a0b1c22', '1719e433-4215-4450-9a72-ae2ec5956224', 'b0d6c1a2-3f4e-4a5b-8c6d-7e8f9a0b1c21', 'assistant',
+     'Im just a computer program, but Im here and ready to help you! How can I assist you today?')
+) AS v(seq, id, chat_id, parent_id, role, content)
+WHERE NOT EXISTS (
+    SELECT 1 FROM messages WHERE chat_id = '1719e433-4215-4450-9a72-ae2ec5956224'
+)
+ORDER BY v.seq;
+
+UPDATE chats SET active_leaf_id = 'b0d6c1a2-3f4e-4a5b-8c6d-7e8f9a0b1c22'
+WHERE id = '1719e433-4215-4450-9a72-ae2ec5956224' AND active_leaf_id IS NULL
+  AND EXISTS (SELECT 1 FROM messages WHERE id = 'b0d6c1a2-3f4e-4a5b-8c6d-7e8f9a0b1c22');
@@ -0,0 +1,234 @@
+// Package eligibility checks clients against the product catalog. It is
+// deterministic and has no dependencies beyond models, so the same answer is
+// given to the API, the LLM and an auditor.
+package eligibility
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"backend/models"
+)
+
+// daysPerMonth converts terms when the client and the product use different
+// units.
+const daysPerMonth = 30
+
+// Evaluate checks every product of the requested segment. Products keep the
+// order they were given in.
+func Evaluate(products []models.Product, req *models.EligibilityRequest) *models.EligibilityResult {
+	result := &models.EligibilityResult{
+		Eligible: []models.ProductMatch{},
+		Rejected: []models.ProductMatch{},
+	}
+
+	var (
+		nearest      *models.ProductMatch
+		nearestScore score
+	)
+	for _, p := range products {
+		if p.Segment != req.Segment {
+			continue
+		}
+
+		match, sc := check(p, req)
+		if sc.failed == 0 {
+			result.Eligible = append(result.Eligible, match)
+			continue
+		}
+		result.Rejected = append(result.Rejected, match)
+		if nearest == nil || sc.less(nearestScore) {
+			m := match
+			nearest, nearestScore = &m, sc
+		}
+	}
+
+	if len(result.Eligible) == 0 {
+		result.NearestAlternative = nearest
+	}
+	return result
+}
+
+// score ranks rejected products: fewer failed rules first, then the smaller
+// total relative distance to the violated bounds.
+type score struct {
+	failed   int
+	distance float64
+}
+
+func (s score) less(o score) bool {
+	if s.failed != o.failed {
+		return s.failed < o.failed
+	}
+	return s.distance < o.distance
+}
+
+func check(p models.Product, req *models.EligibilityRequest) (models.ProductMatch, score) {
+	var (
+		checks []models.RuleCheck
+		sc     score
+	)
+	add := func(rule string, passed bool, distance float64, reason string) {
+		checks = append(checks, models.RuleCheck{Rule: rule, Passed: passed, Reason: reason})
+		if !passed {
+			sc.failed++
+			sc.distance += distance
+		}
+	}
+
+	if req.Age != nil && (p.MinAge != nil || p.MaxAge != nil) {
+		age := int64(*req.Age)
+		passed, distance := inRange(age, Int64Ptr(p.MinAge), Int64Ptr(p.MaxAge))
+		add(models.RuleAge, passed, distance, rangeReason("age", FormatInt(age),
+			FormatRange(Int64Ptr(p.MinAge), Int64Ptr(p.MaxAge), FormatInt, RangeEnglish), passed))
+	}
+
+	if req.Amount != nil && (p.MinAmount != nil || p.MaxAmount != nil) {
+		passed, distance := inRange(*req.Amount, p.MinAmount, p.MaxAmount)
+		add(models.RuleAmount, passed, distance, rangeReason("amount", FormatTenge(*req.Amount)+" ₸",
+			FormatRange(p.MinAmount, p.MaxAmount, FormatTenge, RangeEnglish)+" ₸", passed))
+	}
+
+	if req.Term != nil && (p.MinTerm != nil || p.MaxTerm != nil) {
+		term := convertTerm(int64(*req.Term), termUnit(req.TermUnit), p.TermUnit)
+		passed, distance := inRange(term, Int64Ptr(p.MinTerm), Int64Ptr(p.MaxTerm))
+		unit := unitName(p.TermUnit)
+		add(models.RuleTerm, passed, distance, rangeReason("term", FormatInt(term)+" "+unit,
+			FormatRange(Int64Ptr(p.MinTerm), Int64Ptr(p.MaxTerm), FormatInt, RangeEnglish)+" "+unit, passed))
+	}
+
+	if req.HasCollateral != nil && p.RequiresCollateral {
+		if *req.HasCollateral {
+			add(models.RuleCollateral, true, 0, "collateral is provided")
+		} else {
+			add(models.RuleCollateral, false, 1, "the product requires collateral")
+		}
+	}
+
+	if req.Purpose != "" && len(p.Purposes) > 0 {
+		if slices.Contains(p.Purposes, req.Purpose) {
+			add(models.RulePurpose, true, 0, fmt.Sprintf("the product is meant for %s", req.Purpose))
+		} else {
+			add(models.RulePurpose, false, 1, fmt.Sprintf("the product is meant for %s, not %s",
+				strings.Join(p.Purposes, ", "), req.Purpose))
+		}
+	}
+
+	if checks == nil {
+		checks = []models.RuleCheck{}
+	}
+	return models.ProductMatch{Product: p, Checks: checks}, sc
+}
+
+// inRange reports whether v is within the inclusive bounds and, if not, how
+// far outside it is relative to the violated bound.
+func inRange(v int64, min, max *int64) (bool, float64) {
+	switch {
+	case min != nil && v < *min:
+		return false, relative(*min-v, *min)
+	case max != nil && v > *max:
+		return false, relative(v-*max, *max)
+	default:
+		return true, 0
+	}
+}
+
+func relative(gap, bound int64) float64 {
+	return float64(gap) / math.Max(float64(bound), 1)
+}
+
+func rangeReason(rule, value, bounds string, passed bool) string {
+	if passed {
+		return fmt.Sprintf("%s %s is within %s", rule, value, bounds)
+	}
+	return fmt.Sprintf("%s %s is outside %s", rule, value, bounds)
+}
+
+func termUnit(unit string) string {
+	if unit == "" {
+		return models.TermUnitMonth
+	}
+	return unit
+}
+
+func convertTerm(term int64, from, to string) int64 {
+	switch {
+	case from == to:
+		return term
+	case from == models.TermUnitMonth:
+		return term * daysPerMonth
+	default:
+		// Partial months round up: 31 days do not fit a 1-month product.
+		return (term + daysPerMonth - 1) / daysPerMonth
+	}
+}
+
+func unitName(unit string) string {
+	if unit == models.TermUnitDay {
+		return "days"
+	}
+	return "months"
+}
+
+// Int64Ptr widens an optional int bound for FormatRange.
+func Int64Ptr(v *int) *int64 {
+	if v == nil {
+		return nil
+	}
+	n := int64(*v)
+	return &n
+}
+
+// RangeWords name an open-ended range in FormatRange.
+type RangeWords struct {
+	From, UpTo string
+}
+
+var (
+	RangeEnglish = RangeWords{From: "from", UpTo: "up to"}
+	RangeRussian = RangeWords{From: "от", UpTo: "до"}
+)
+
+// FormatRange renders inclusive bounds, either of which may be missing:
+// "10–20", "from 10", "up to 20", or "" without bounds. The reasons given by
+// Evaluate and the catalog in the system prompt both use it, so they quote
+// the same limits.
+func FormatRange(min, max *int64, format func(int64) string, words RangeWords) string {
+	switch {
+	case min != nil && max != nil:
+		return format(*min) + "–" + format(*max)
+	case min != nil:
+		return words.From + " " + format(*min)
+	case max != nil:
+		return words.UpTo + " " + format(*max)
+	default:
+		return ""
+	}
+}
+
+func FormatInt(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
+
+// FormatTenge groups thousands with spaces: 5000000 -> "5 000 000".
+func FormatTenge(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	if len(digits) <= 3 {
+		return digits
+	}
+	var b strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
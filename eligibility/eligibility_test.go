@@ -0,0 +1,211 @@
+package eligibility
+
+import (
+	"testing"
+
+	"backend/models"
+)
+
+func i(v int) *int       { return &v }
+func i64(v int64) *int64 { return &v }
+func b(v bool) *bool     { return &v }
+
+// catalog mirrors the limits of the seeded products (migrations 0007, 0008).
+func catalog() []models.Product {
+	return []models.Product{
+		{Code: "bnpl", Segment: models.SegmentRetail, MinAmount: i64(10000), MaxAmount: i64(300000),
+			MinTerm: i(1), MaxTerm: i(12), TermUnit: models.TermUnitMonth, MinAge: i(18), MaxAge: i(63),
+			Purposes: []string{models.PurposePurchase}},
+		{Code: "islamic_financing", Segment: models.SegmentRetail, MinAmount: i64(100000), MaxAmount: i64(5000000),
+			MinTerm: i(3), MaxTerm: i(60), TermUnit: models.TermUnitMonth, MinAge: i(18), MaxAge: i(60),
+			Purposes: []string{models.PurposePurchase}},
+		{Code: "islamic_mortgage", Segment: models.SegmentRetail, MinAmount: i64(3000000), MaxAmount: i64(75000000),
+			MinTerm: i(12), MaxTerm: i(240), TermUnit: models.TermUnitMonth, MinAge: i(25), MaxAge: i(60),
+			Purposes: []string{models.PurposeHousing}},
+		{Code: "business_overdraft", Segment: models.SegmentBusiness, MaxAmount: i64(10000000),
+			MaxTerm: i(30), TermUnit: models.TermUnitDay, MinAge: i(21), MaxAge: i(63),
+			Purposes: []string{models.PurposeLiquidity}},
+		{Code: "business_secured", Segment: models.SegmentBusiness, MinAmount: i64(100000), MaxAmount: i64(10000000),
+			MinTerm: i(3), MaxTerm: i(60), TermUnit: models.TermUnitMonth, MinAge: i(21), MaxAge: i(63),
+			Purposes: []string{models.PurposeEquipment, models.PurposeInvestment}, RequiresCollateral: true},
+	}
+}
+
+// ruleOutcome finds how product code fared on rule: whether the product was
+// eligible, and whether the rule passed. ok is false if the rule was not
+// checked at all.
+func ruleOutcome(t *testing.T, res *models.EligibilityResult, code, rule string) (eligible, passed, ok bool) {
+	t.Helper()
+	for _, list := range []struct {
+		matches  []models.ProductMatch
+		eligible bool
+	}{{res.Eligible, true}, {res.Rejected, false}} {
+		for _, m := range list.matches {
+			if m.Product.Code != code {
+				continue
+			}
+			for _, c := range m.Checks {
+				if c.Rule == rule {
+					return list.eligible, c.Passed, true
+				}
+			}
+			return list.eligible, false, false
+		}
+	}
+	t.Fatalf("product %s missing from the result", code)
+	return false, false, false
+}
+
+func TestEvaluateBoundaries(t *testing.T) {
+	tests := []struct {
+		name    string
+		product string
+		rule    string
+		req     models.EligibilityRequest
+		pass    bool
+	}{
+		// BNPL amount 10 000–300 000 ₸.
+		{"bnpl amount below min", "bnpl", models.RuleAmount, models.EligibilityRequest{Amount: i64(9999)}, false},
+		{"bnpl amount at min", "bnpl", models.RuleAmount, models.EligibilityRequest{Amount: i64(10000)}, true},
+		{"bnpl amount at max", "bnpl", models.RuleAmount, models.EligibilityRequest{Amount: i64(300000)}, true},
+		{"bnpl amount above max", "bnpl", models.RuleAmount, models.EligibilityRequest{Amount: i64(300001)}, false},
+		// BNPL term 1–12 months.
+		{"bnpl term below min", "bnpl", models.RuleTerm, models.EligibilityRequest{Term: i(0)}, false},
+		{"bnpl term at min", "bnpl", models.RuleTerm, models.EligibilityRequest{Term: i(1)}, true},
+		{"bnpl term at max", "bnpl", models.RuleTerm, models.EligibilityRequest{Term: i(12)}, true},
+		{"bnpl term above max", "bnpl", models.RuleTerm, models.EligibilityRequest{Term: i(13)}, false},
+		{"bnpl term in days rounds up", "bnpl", models.RuleTerm,
+			models.EligibilityRequest{Term: i(361), TermUnit: models.TermUnitDay}, false},
+		{"bnpl term in days at max", "bnpl", models.RuleTerm,
+			models.EligibilityRequest{Term: i(360), TermUnit: models.TermUnitDay}, true},
+		// Age 18–63 (BNPL).
+		{"bnpl age 17", "bnpl", models.RuleAge, models.EligibilityRequest{Age: i(17)}, false},
+		{"bnpl age 18", "bnpl", models.RuleAge, models.EligibilityRequest{Age: i(18)}, true},
+		{"bnpl age 63", "bnpl", models.RuleAge, models.EligibilityRequest{Age: i(63)}, true},
+		{"bnpl age 64", "bnpl", models.RuleAge, models.EligibilityRequest{Age: i(64)}, false},
+		// Age 18–60 (Islamic financing).
+		{"financing age 17", "islamic_financing", models.RuleAge, models.EligibilityRequest{Age: i(17)}, false},
+		{"financing age 18", "islamic_financing", models.RuleAge, models.EligibilityRequest{Age: i(18)}, true},
+		{"financing age 60", "islamic_financing", models.RuleAge, models.EligibilityRequest{Age: i(60)}, true},
+		{"financing age 61", "islamic_financing", models.RuleAge, models.EligibilityRequest{Age: i(61)}, false},
+		// Age 25–60 (Islamic mortgage).
+		{"mortgage age 24", "islamic_mortgage", models.RuleAge, models.EligibilityRequest{Age: i(24)}, false},
+		{"mortgage age 25", "islamic_mortgage", models.RuleAge, models.EligibilityRequest{Age: i(25)}, true},
+		{"mortgage age 60", "islamic_mortgage", models.RuleAge, models.EligibilityRequest{Age: i(60)}, true},
+		{"mortgage age 61", "islamic_mortgage", models.RuleAge, models.EligibilityRequest{Age: i(61)}, false},
+		// Age 21–63 (business financing).
+		{"business age 20", "business_secured", models.RuleAge,
+			models.EligibilityRequest{Segment: models.SegmentBusiness, Age: i(20)}, false},
+		{"business age 21", "business_secured", models.RuleAge,
+			models.EligibilityRequest{Segment: models.SegmentBusiness, Age: i(21)}, true},
+		{"business age 63", "business_secured", models.RuleAge,
+			models.EligibilityRequest{Segment: models.SegmentBusiness, Age: i(63)}, true},
+		{"business age 64", "business_secured", models.RuleAge,
+			models.EligibilityRequest{Segment: models.SegmentBusiness, Age: i(64)}, false},
+		// Overdraft up to 30 days; months convert to days.
+		{"overdraft 30 days", "business_overdraft", models.RuleTerm,
+			models.EligibilityRequest{Segment: models.SegmentBusiness, Term: i(30), TermUnit: models.TermUnitDay}, true},
+		{"overdraft 31 days", "business_overdraft", models.RuleTerm,
+			models.EligibilityRequest{Segment: models.SegmentBusiness, Term: i(31), TermUnit: models.TermUnitDay}, false},
+		{"overdraft 1 month", "business_overdraft", models.RuleTerm,
+			models.EligibilityRequest{Segment: models.SegmentBusiness, Term: i(1)}, true},
+		{"overdraft 2 months", "business_overdraft", models.RuleTerm,
+			models.EligibilityRequest{Segment: models.SegmentBusiness, Term: i(2)}, false},
+		// Collateral.
+		{"secured without collateral", "business_secured", models.RuleCollateral,
+			models.EligibilityRequest{Segment: models.SegmentBusiness, HasCollateral: b(false)}, false},
+		{"secured with collateral", "business_secured", models.RuleCollateral,
+			models.EligibilityRequest{Segment: models.SegmentBusiness, HasCollateral: b(true)}, true},
+		// Purpose.
+		{"bnpl for a purchase", "bnpl", models.RulePurpose,
+			models.EligibilityRequest{Purpose: models.PurposePurchase}, true},
+		{"bnpl for housing", "bnpl", models.RulePurpose,
+			models.EligibilityRequest{Purpose: models.PurposeHousing}, false},
+		{"secured for equipment", "business_secured", models.RulePurpose,
+			models.EligibilityRequest{Segment: models.SegmentBusiness, Purpose: models.PurposeEquipment}, true},
+		{"secured for liquidity", "business_secured", models.RulePurpose,
+			models.EligibilityRequest{Segment: models.SegmentBusiness, Purpose: models.PurposeLiquidity}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			if req.Segment == "" {
+				req.Segment = models.SegmentRetail
+			}
+			res := Evaluate(catalog(), &req)
+			eligible, passed, ok := ruleOutcome(t, res, tt.product, tt.rule)
+			if !ok {
+				t.Fatalf("rule %s was not checked for %s", tt.rule, tt.product)
+			}
+			if passed != tt.pass || eligible != tt.pass {
+				t.Errorf("%s: rule passed %v, eligible %v; want %v", tt.product, passed, eligible, tt.pass)
+			}
+		})
+	}
+}
+
+func TestEvaluateSkipsUnknownCriteria(t *testing.T) {
+	res := Evaluate(catalog(), &models.EligibilityRequest{Segment: models.SegmentRetail})
+	if len(res.Eligible) != 3 || len(res.Rejected) != 0 {
+		t.Fatalf("got %d eligible, %d rejected; want every retail product eligible", len(res.Eligible), len(res.Rejected))
+	}
+	if res.NearestAlternative != nil {
+		t.Error("nearest alternative set although products are eligible")
+	}
+	// Collateral is only checked where the product requires it.
+	if _, _, ok := ruleOutcome(t, Evaluate(catalog(), &models.EligibilityRequest{
+		Segment: models.SegmentRetail, HasCollateral: b(false),
+	}), "bnpl", models.RuleCollateral); ok {
+		t.Error("collateral checked for a product that does not require it")
+	}
+}
+
+func TestEvaluateNearestAlternative(t *testing.T) {
+	tests := []struct {
+		name string
+		req  models.EligibilityRequest
+		want string
+	}{
+		// BNPL misses only its amount, by far; the financing and the
+		// mortgage miss two rules each, narrowly.
+		{"fewest failed rules", models.EligibilityRequest{Segment: models.SegmentRetail, Age: i(61), Amount: i64(5100000),
+			Purpose: models.PurposePurchase}, "bnpl"},
+		// One rule each: BNPL misses its amount by a sixth, the financing
+		// its minimum term by a third.
+		{"smallest distance", models.EligibilityRequest{Segment: models.SegmentRetail, Amount: i64(350000), Term: i(2)}, "bnpl"},
+		{"closer to the mortgage", models.EligibilityRequest{Segment: models.SegmentRetail, Amount: i64(2900000),
+			Term: i(120), Purpose: models.PurposeHousing}, "islamic_mortgage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := Evaluate(catalog(), &tt.req)
+			if len(res.Eligible) != 0 {
+				t.Fatalf("expected nothing eligible, got %d", len(res.Eligible))
+			}
+			if res.NearestAlternative == nil {
+				t.Fatal("no nearest alternative")
+			}
+			if got := res.NearestAlternative.Product.Code; got != tt.want {
+				t.Errorf("nearest alternative %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatRange(t *testing.T) {
+	tests := []struct {
+		min, max *int64
+		words    RangeWords
+		want     string
+	}{
+		{i64(10000), i64(300000), RangeEnglish, "10 000–300 000"},
+		{i64(50000), nil, RangeEnglish, "from 50 000"},
+		{nil, i64(10000000), RangeRussian, "до 10 000 000"},
+		{nil, nil, RangeRussian, ""},
+	}
+	for _, tt := range tests {
+		if got := FormatRange(tt.min, tt.max, FormatTenge, tt.words); got != tt.want {
+			t.Errorf("FormatRange = %q, want %q", got, tt.want)
+		}
+	}
+}
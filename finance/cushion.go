@@ -0,0 +1,33 @@
+// Package finance holds the calculators behind the numbers the coach quotes.
+// Everything here is pure arithmetic on tenge amounts.
+package finance
+
+import "backend/models"
+
+// kopilkaShare is the part of the cushion kept in the flexible product; the
+// rest goes to "Вакала" for yield.
+const kopilkaShare = 70
+
+// Cushion sizes a safety cushion for the given monthly expenses.
+func Cushion(req *models.CushionRequest) models.CushionPlan {
+	months := req.Months
+	if months == 0 {
+		months = models.CushionMaxMonths
+	}
+
+	plan := models.CushionPlan{
+		MinTarget: req.MonthlyExpenses * models.CushionMinMonths,
+		MaxTarget: req.MonthlyExpenses * models.CushionMaxMonths,
+		Months:    months,
+		Target:    req.MonthlyExpenses * int64(months),
+	}
+	plan.KopilkaAmount = plan.Target * kopilkaShare / 100
+	plan.VakalaAmount = plan.Target - plan.KopilkaAmount
+	plan.Remaining = max(plan.Target-req.CurrentSavings, 0)
+
+	if req.MonthlyContribution > 0 {
+		n := int((plan.Remaining + req.MonthlyContribution - 1) / req.MonthlyContribution)
+		plan.MonthsToGoal = &n
+	}
+	return plan
+}
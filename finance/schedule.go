@@ -0,0 +1,108 @@
+package finance
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"backend/models"
+)
+
+var ErrInvalidSchedule = errors.New("invalid schedule request")
+
+// BuildSchedule produces the monthly payment plan for req.
+func BuildSchedule(req *models.ScheduleRequest) (*models.Schedule, error) {
+	if req.Term < 1 {
+		return nil, fmt.Errorf("%w: term must be at least one month", ErrInvalidSchedule)
+	}
+
+	s := &models.Schedule{
+		Kind:       req.Kind,
+		Price:      req.Amount,
+		Financed:   req.Amount,
+		Term:       req.Term,
+		MarkupRate: req.MarkupRate,
+	}
+
+	switch req.Kind {
+	case models.ScheduleBNPL:
+		s.MarkupRate = 0
+		s.Periods = flatSchedule(s.Financed, 0, req.Term)
+	case models.ScheduleMurabaha:
+		s.Periods = flatSchedule(s.Financed, murabahaMarkup(s.Financed, req.MarkupRate, req.Term), req.Term)
+	case models.ScheduleMortgage:
+		if req.DownPayment >= req.Amount {
+			return nil, fmt.Errorf("%w: down payment must be less than the price", ErrInvalidSchedule)
+		}
+		s.DownPayment = req.DownPayment
+		s.Financed = req.Amount - req.DownPayment
+		s.Periods = annuitySchedule(s.Financed, req.MarkupRate, req.Term)
+	default:
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidSchedule, req.Kind)
+	}
+
+	for _, p := range s.Periods {
+		s.TotalMarkup += p.Markup
+		s.TotalPayment += p.Payment
+	}
+	s.MonthlyPayment = s.Periods[0].Payment
+	return s, nil
+}
+
+// murabahaMarkup is the markup fixed in the contract: the annual rate applied
+// to the cost for the whole term, independent of early repayments.
+func murabahaMarkup(cost int64, rate float64, months int) int64 {
+	return int64(math.Round(float64(cost) * rate / 100 * float64(months) / 12))
+}
+
+// flatSchedule splits the total owed into equal payments, each carrying an
+// equal share of the markup.
+func flatSchedule(principal, markup int64, months int) []models.SchedulePeriod {
+	periods := make([]models.SchedulePeriod, months)
+	remaining := principal + markup
+	for i := range periods {
+		p := models.SchedulePeriod{
+			Period: i + 1,
+			Markup: markup / int64(months),
+		}
+		p.Principal = (principal+markup)/int64(months) - p.Markup
+		if i == months-1 {
+			p.Principal = principal - p.Principal*int64(months-1)
+			p.Markup = markup - p.Markup*int64(months-1)
+		}
+		p.Payment = p.Principal + p.Markup
+		remaining -= p.Payment
+		p.Remaining = remaining
+		periods[i] = p
+	}
+	return periods
+}
+
+// annuitySchedule charges markup monthly on the outstanding share with equal
+// payments, as in diminishing partnership home finance.
+func annuitySchedule(principal int64, rate float64, months int) []models.SchedulePeriod {
+	r := rate / 100 / 12
+	payment := float64(principal) / float64(months)
+	if r > 0 {
+		payment = float64(principal) * r / (1 - math.Pow(1+r, -float64(months)))
+	}
+	monthly := int64(math.Round(payment))
+
+	periods := make([]models.SchedulePeriod, months)
+	outstanding := principal
+	for i := range periods {
+		p := models.SchedulePeriod{
+			Period: i + 1,
+			Markup: int64(math.Round(float64(outstanding) * r)),
+		}
+		p.Principal = monthly - p.Markup
+		if i == months-1 || p.Principal > outstanding {
+			p.Principal = outstanding
+		}
+		p.Payment = p.Principal + p.Markup
+		outstanding -= p.Principal
+		p.Remaining = outstanding
+		periods[i] = p
+	}
+	return periods
+}
@@ -0,0 +1,138 @@
+package finance
+
+import (
+	"errors"
+	"testing"
+
+	"backend/models"
+)
+
+// checkTotals verifies what every schedule must satisfy: the principal parts
+// repay exactly what was financed, the payments add up to principal plus
+// markup to the tenge, and the balance ends at zero.
+func checkTotals(t *testing.T, s *models.Schedule) {
+	t.Helper()
+	if len(s.Periods) != s.Term {
+		t.Fatalf("%d periods for a %d-month term", len(s.Periods), s.Term)
+	}
+	var principal, markup, payment int64
+	for _, p := range s.Periods {
+		if p.Payment != p.Principal+p.Markup {
+			t.Errorf("period %d: payment %d != principal %d + markup %d", p.Period, p.Payment, p.Principal, p.Markup)
+		}
+		if p.Principal < 0 || p.Markup < 0 {
+			t.Errorf("period %d: negative part %+v", p.Period, p)
+		}
+		principal += p.Principal
+		markup += p.Markup
+		payment += p.Payment
+	}
+	if principal != s.Financed {
+		t.Errorf("principal repaid %d, financed %d", principal, s.Financed)
+	}
+	if markup != s.TotalMarkup || payment != s.TotalPayment {
+		t.Errorf("period sums markup %d payment %d, totals %d %d", markup, payment, s.TotalMarkup, s.TotalPayment)
+	}
+	if s.TotalPayment != s.Financed+s.TotalMarkup {
+		t.Errorf("total payment %d != financed %d + markup %d", s.TotalPayment, s.Financed, s.TotalMarkup)
+	}
+	if last := s.Periods[len(s.Periods)-1]; last.Remaining != 0 {
+		t.Errorf("balance after the last period is %d", last.Remaining)
+	}
+	if s.MonthlyPayment != s.Periods[0].Payment {
+		t.Errorf("monthly payment %d, first period %d", s.MonthlyPayment, s.Periods[0].Payment)
+	}
+}
+
+func TestBuildScheduleSettlesInLastPeriod(t *testing.T) {
+	tests := []struct {
+		name        string
+		req         models.ScheduleRequest
+		markup      int64
+		regular     int64 // every payment but the last
+		lastPayment int64
+	}{
+		{"bnpl even split", models.ScheduleRequest{Kind: models.ScheduleBNPL, Amount: 120000, Term: 12},
+			0, 10000, 10000},
+		{"bnpl remainder", models.ScheduleRequest{Kind: models.ScheduleBNPL, Amount: 100000, Term: 3},
+			0, 33333, 33334},
+		{"bnpl ignores markup", models.ScheduleRequest{Kind: models.ScheduleBNPL, Amount: 299999, Term: 12, MarkupRate: 30},
+			0, 24999, 25010},
+		{"murabaha whole year", models.ScheduleRequest{Kind: models.ScheduleMurabaha, Amount: 1000000, Term: 12, MarkupRate: 12},
+			120000, 93333, 93337},
+		{"murabaha rounded markup", models.ScheduleRequest{Kind: models.ScheduleMurabaha, Amount: 333333, Term: 7, MarkupRate: 17.5},
+			34028, 52480, 52481},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := BuildSchedule(&tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkTotals(t, s)
+			if s.TotalMarkup != tt.markup {
+				t.Errorf("total markup %d, want %d", s.TotalMarkup, tt.markup)
+			}
+			for _, p := range s.Periods[:len(s.Periods)-1] {
+				if p.Payment != tt.regular {
+					t.Fatalf("period %d pays %d, want %d", p.Period, p.Payment, tt.regular)
+				}
+			}
+			if last := s.Periods[len(s.Periods)-1]; last.Payment != tt.lastPayment {
+				t.Errorf("last payment %d, want %d", last.Payment, tt.lastPayment)
+			}
+		})
+	}
+}
+
+func TestBuildScheduleMortgage(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.ScheduleRequest
+		monthly int64
+	}{
+		{"twenty years", models.ScheduleRequest{Kind: models.ScheduleMortgage, Amount: 30000000, DownPayment: 6000000,
+			Term: 240, MarkupRate: 12}, 264261},
+		{"one year", models.ScheduleRequest{Kind: models.ScheduleMortgage, Amount: 3000000, DownPayment: 1000000,
+			Term: 12, MarkupRate: 9.5}, 175367},
+		{"no markup", models.ScheduleRequest{Kind: models.ScheduleMortgage, Amount: 1000000, Term: 3}, 333333},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := BuildSchedule(&tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkTotals(t, s)
+			if s.Financed != tt.req.Amount-tt.req.DownPayment {
+				t.Errorf("financed %d, want price minus down payment", s.Financed)
+			}
+			if s.MonthlyPayment != tt.monthly {
+				t.Errorf("monthly payment %d, want %d", s.MonthlyPayment, tt.monthly)
+			}
+			// Rounded markups drift a little over a long term; the last
+			// payment absorbs it, staying within 1% of the others.
+			last := s.Periods[len(s.Periods)-1].Payment
+			if diff := last - tt.monthly; diff < -tt.monthly/100 || diff > tt.monthly/100 {
+				t.Errorf("last payment %d strays from %d", last, tt.monthly)
+			}
+			for i := 1; i < len(s.Periods); i++ {
+				if s.Periods[i].Markup > s.Periods[i-1].Markup {
+					t.Fatalf("markup grew from period %d to %d", i, i+1)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildScheduleRejects(t *testing.T) {
+	for _, req := range []models.ScheduleRequest{
+		{Kind: models.ScheduleBNPL, Amount: 1000, Term: 0},
+		{Kind: models.ScheduleMortgage, Amount: 1000, DownPayment: 1000, Term: 12},
+		{Kind: "lease", Amount: 1000, Term: 12},
+	} {
+		if _, err := BuildSchedule(&req); !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("%+v: got %v, want ErrInvalidSchedule", req, err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"backend/auth"
+	"backend/models"
+	"backend/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) Register(c echo.Context) error {
+	var req models.RegisterRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid JSON body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: err.Error()})
+	}
+
+	resp, err := h.service.Register(c.Request().Context(), &req)
+	if err != nil {
+		return authError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Data:    resp,
+	})
+}
+
+func (h *Handler) Login(c echo.Context) error {
+	var req models.LoginRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid JSON body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: err.Error()})
+	}
+
+	resp, err := h.service.Login(c.Request().Context(), &req)
+	if err != nil {
+		return authError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    resp,
+	})
+}
+
+// Me returns the authenticated user.
+func (h *Handler) Me(c echo.Context) error {
+	user, err := h.service.GetUser(c.Request().Context(), auth.UserID(c))
+	if err != nil {
+		return authError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    user,
+	})
+}
+
+// ClaimGuest moves the chats of a guest session to the authenticated account.
+func (h *Handler) ClaimGuest(c echo.Context) error {
+	var req models.ClaimGuestRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid JSON body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: err.Error()})
+	}
+
+	claimed, err := h.service.ClaimGuestChats(c.Request().Context(), auth.UserID(c), req.GuestToken)
+	if err != nil {
+		return authError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    map[string]int64{"claimed_chats": claimed},
+	})
+}
+
+func authError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidGuestToken):
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: err.Error()})
+	case errors.Is(err, services.ErrEmailTaken):
+		return c.JSON(http.StatusConflict, Response{Success: false, Error: err.Error()})
+	case errors.Is(err, services.ErrInvalidCredentials):
+		return c.JSON(http.StatusUnauthorized, Response{Success: false, Error: err.Error()})
+	case errors.Is(err, models.ErrNotFound):
+		return c.JSON(http.StatusNotFound, Response{Success: false, Error: "user not found"})
+	default:
+		return c.JSON(http.StatusInternalServerError, Response{Success: false, Error: err.Error()})
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backend/finance"
+	"backend/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CalculateSchedule returns a monthly payment plan. With ?format=csv the
+// periods are sent as a CSV attachment instead of JSON.
+func (h *Handler) CalculateSchedule(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format != "" && format != "json" && format != "csv" {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "format must be json or csv"})
+	}
+
+	var req models.ScheduleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid JSON body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: err.Error()})
+	}
+
+	schedule, err := h.service.CalculateSchedule(c.Request().Context(), &req)
+	if errors.Is(err, finance.ErrInvalidSchedule) {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, Response{Success: false, Error: err.Error()})
+	}
+
+	if format == "csv" {
+		return writeScheduleCSV(c, schedule)
+	}
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    schedule,
+	})
+}
+
+func writeScheduleCSV(c echo.Context, schedule *models.Schedule) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="schedule-`+schedule.Kind+`.csv"`)
+	res.WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(res)
+	_ = w.Write([]string{"period", "payment", "principal", "markup", "remaining"})
+	for _, p := range schedule.Periods {
+		_ = w.Write([]string{
+			strconv.Itoa(p.Period),
+			strconv.FormatInt(p.Payment, 10),
+			strconv.FormatInt(p.Principal, 10),
+			strconv.FormatInt(p.Markup, 10),
+			strconv.FormatInt(p.Remaining, 10),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"backend/auth"
+	"backend/llm"
+	"backend/models"
+	"backend/services"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ListChats returns the caller's chats ordered by last activity.
+// Query: limit, cursor (from next_cursor of the previous page), archived=true.
+func (h *Handler) ListChats(c echo.Context) error {
+	params := models.ChatListParams{Cursor: c.QueryParam("cursor")}
+
+	if v := c.QueryParam("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid limit"})
+		}
+		params.Limit = limit
+	}
+	if v := c.QueryParam("archived"); v != "" {
+		archived, err := strconv.ParseBool(v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid archived flag"})
+		}
+		params.Archived = archived
+	}
+
+	page, err := h.service.ListChats(c.Request().Context(), auth.UserID(c), params)
+	if err != nil {
+		return chatError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    page,
+	})
+}
+
+// UpdateChat renames and/or archives a chat.
+func (h *Handler) UpdateChat(c echo.Context) error {
+	chatID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid chat id"})
+	}
+
+	var req models.UpdateChatRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid JSON body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: err.Error()})
+	}
+	if req.Title == nil && req.Archived == nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "nothing to update"})
+	}
+
+	chat, err := h.service.UpdateChat(c.Request().Context(), auth.UserID(c), chatID, &req)
+	if err != nil {
+		return chatError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    chat,
+	})
+}
+
+// DeleteChat removes a chat together with its messages.
+func (h *Handler) DeleteChat(c echo.Context) error {
+	chatID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid chat id"})
+	}
+
+	if err := h.service.DeleteChat(c.Request().Context(), auth.UserID(c), chatID); err != nil {
+		return chatError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RetryMessage generates a failed reply again. The body is optional.
+func (h *Handler) RetryMessage(c echo.Context) error {
+	chatID, messageID, ok := chatMessageIDs(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid chat or message id"})
+	}
+
+	var req models.RetryRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid JSON body"})
+	}
+
+	opts := models.ReplyOptions{Structured: req.Structured}
+	reply, err := h.service.RetryMessage(c.Request().Context(), auth.UserID(c), chatID, messageID, opts)
+	if err != nil {
+		return chatError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    reply,
+	})
+}
+
+// RegenerateMessage answers the question of an assistant reply again as a new
+// branch. The body is optional.
+func (h *Handler) RegenerateMessage(c echo.Context) error {
+	chatID, messageID, ok := chatMessageIDs(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid chat or message id"})
+	}
+
+	var req models.RetryRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid JSON body"})
+	}
+
+	opts := models.ReplyOptions{Structured: req.Structured}
+	reply, err := h.service.RegenerateMessage(c.Request().Context(), auth.UserID(c), chatID, messageID, opts)
+	if err != nil {
+		return chatError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    reply,
+	})
+}
+
+// EditMessage resends a user message with new content as a new branch and
+// returns the new message with its reply.
+func (h *Handler) EditMessage(c echo.Context) error {
+	chatID, messageID, ok := chatMessageIDs(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid chat or message id"})
+	}
+
+	var req models.EditMessageRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid JSON body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: err.Error()})
+	}
+
+	opts := models.ReplyOptions{Structured: req.Structured}
+	turn, err := h.service.EditMessage(c.Request().Context(), auth.UserID(c), chatID, messageID, req.Content, opts)
+	if err != nil {
+		return chatError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    turn,
+	})
+}
+
+// ActivateMessage switches the chat to the branch through a message and
+// returns the chat with that branch.
+func (h *Handler) ActivateMessage(c echo.Context) error {
+	chatID, messageID, ok := chatMessageIDs(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid chat or message id"})
+	}
+
+	chat, err := h.service.ActivateMessage(c.Request().Context(), auth.UserID(c), chatID, messageID)
+	if err != nil {
+		return chatError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    chat,
+	})
+}
+
+func chatMessageIDs(c echo.Context) (chatID, messageID uuid.UUID, ok bool) {
+	chatID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false
+	}
+	messageID, err = uuid.Parse(c.Param("messageId"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false
+	}
+	return chatID, messageID, true
+}
+
+// ExportChat downloads the chat's active branch. Query: format=md|json|html,
+// Markdown by default.
+func (h *Handler) ExportChat(c echo.Context) error {
+	chatID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid chat id"})
+	}
+	format := c.QueryParam("format")
+	if format == "" {
+		format = models.ExportMarkdown
+	}
+
+	file, err := h.service.ExportChat(c.Request().Context(), auth.UserID(c), chatID, format)
+	if err != nil {
+		return chatError(c, err)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+file.Name+`"`)
+	return c.Blob(http.StatusOK, file.ContentType, file.Body)
+}
+
+// ImportChat restores a JSON export as a new chat of the caller.
+func (h *Handler) ImportChat(c echo.Context) error {
+	var export models.ChatExport
+	if err := c.Bind(&export); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid JSON body"})
+	}
+
+	chat, err := h.service.ImportChat(c.Request().Context(), auth.UserID(c), &export)
+	if err != nil {
+		return chatError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    chat,
+	})
+}
+
+// chatError maps service errors onto HTTP responses.
+func chatError(c echo.Context, err error) error {
+	var perr *llm.ProviderError
+	switch {
+	case errors.As(err, &perr):
+		return providerError(c, perr)
+	case errors.Is(err, models.ErrNotFound):
+		return c.JSON(http.StatusNotFound, Response{Success: false, Error: "chat not found"})
+	case errors.Is(err, services.ErrInvalidCursor), errors.Is(err, services.ErrEmptyTitle),
+		errors.Is(err, services.ErrInvalidQuery), errors.Is(err, services.ErrUnknownExportFormat),
+		errors.Is(err, services.ErrInvalidImport),
+		errors.Is(err, services.ErrUnknownSuggestion), errors.Is(err, errContentOrSuggestion),
+		errors.Is(err, services.ErrUnknownModel), errors.Is(err, services.ErrNotRegenerable),
+		errors.Is(err, services.ErrNotEditable):
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: err.Error()})
+	case errors.Is(err, services.ErrNotRetryable), errors.Is(err, services.ErrReplyPending):
+		return c.JSON(http.StatusConflict, Response{Success: false, Error: err.Error()})
+	case errors.Is(err, llm.ErrContextTooLong):
+		return c.JSON(http.StatusRequestEntityTooLarge, Response{Success: false, Error: err.Error()})
+	case errors.Is(err, services.ErrToolLoop):
+		return c.JSON(http.StatusBadGateway, Response{Success: false, Error: err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, Response{Success: false, Error: err.Error()})
+	}
+}
+
+// providerErrorStatus maps LLM provider failures: our rate limit is passed on,
+// a provider we cannot reach or that is overloaded is a bad gateway, and an
+// open circuit means we are not even trying. Rejected requests and bad keys
+// are our own fault and logged, the client only sees a bad gateway.
+var providerErrorStatus = map[string]int{
+	llm.KindRateLimited: http.StatusTooManyRequests,
+	llm.KindOverloaded:  http.StatusBadGateway,
+	llm.KindUnavailable: http.StatusServiceUnavailable,
+	llm.KindBadRequest:  http.StatusBadGateway,
+	llm.KindAuth:        http.StatusBadGateway,
+}
+
+var providerErrorText = map[string]string{
+	llm.KindRateLimited: "the assistant is receiving too many requests, try again later",
+	llm.KindOverloaded:  "the assistant is temporarily overloaded, try again later",
+	llm.KindUnavailable: "the assistant is temporarily unavailable, try again later",
+	llm.KindBadRequest:  "the assistant could not process the request",
+	llm.KindAuth:        "the assistant is misconfigured",
+}
+
+// errorText is what clients are told about err. Provider responses stay in
+// the logs.
+func errorText(err error) string {
+	var perr *llm.ProviderError
+	if errors.As(err, &perr) {
+		return providerErrorText[perr.Kind]
+	}
+	return err.Error()
+}
+
+func providerError(c echo.Context, perr *llm.ProviderError) error {
+	status, ok := providerErrorStatus[perr.Kind]
+	if !ok {
+		status = http.StatusBadGateway
+	}
+	if status >= http.StatusInternalServerError || perr.Kind == llm.KindAuth {
+		log.Printf("LLM provider error: %v", perr)
+	}
+	if perr.RetryAfter > 0 {
+		secs := int(math.Ceil(perr.RetryAfter.Seconds()))
+		c.Response().Header().Set("Retry-After", strconv.Itoa(secs))
+	}
+	return c.JSON(status, Response{
+		Success: false,
+		Error:   errorText(perr),
+		Code:    llm.ErrorCode(perr),
+	})
+}
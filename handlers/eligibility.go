@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"backend/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CheckEligibility lists the products the described client qualifies for,
+// with the reason behind every rule.
+func (h *Handler) CheckEligibility(c echo.Context) error {
+	var req models.EligibilityRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid JSON body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: err.Error()})
+	}
+
+	result, err := h.service.CheckEligibility(c.Request().Context(), &req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, Response{Success: false, Error: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    result,
+	})
+}
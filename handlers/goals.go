@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"backend/auth"
+	"backend/models"
+	"backend/services"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ListGoals returns the caller's goals with progress. Query: status.
+func (h *Handler) ListGoals(c echo.Context) error {
+	status := c.QueryParam("status")
+	switch status {
+	case "", models.GoalStatusActive, models.GoalStatusAchieved, models.GoalStatusArchived:
+	default:
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid status"})
+	}
+
+	goals, err := h.service.ListGoals(c.Request().Context(), auth.UserID(c), status)
+	if err != nil {
+		return goalError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    goals,
+	})
+}
+
+func (h *Handler) GetGoal(c echo.Context) error {
+	goalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid goal id"})
+	}
+
+	goal, err := h.service.GetGoal(c.Request().Context(), auth.UserID(c), goalID)
+	if err != nil {
+		return goalError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    goal,
+	})
+}
+
+func (h *Handler) CreateGoal(c echo.Context) error {
+	var req models.CreateGoalRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid JSON body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: err.Error()})
+	}
+
+	goal, err := h.service.CreateGoal(c.Request().Context(), auth.UserID(c), &req)
+	if err != nil {
+		return goalError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Data:    goal,
+	})
+}
+
+func (h *Handler) UpdateGoal(c echo.Context) error {
+	goalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid goal id"})
+	}
+
+	var req models.UpdateGoalRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid JSON body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: err.Error()})
+	}
+
+	goal, err := h.service.UpdateGoal(c.Request().Context(), auth.UserID(c), goalID, &req)
+	if err != nil {
+		return goalError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    goal,
+	})
+}
+
+func (h *Handler) DeleteGoal(c echo.Context) error {
+	goalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid goal id"})
+	}
+
+	if err := h.service.DeleteGoal(c.Request().Context(), auth.UserID(c), goalID); err != nil {
+		return goalError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// CreateGoalAction adds a micro-action and returns the updated goal.
+func (h *Handler) CreateGoalAction(c echo.Context) error {
+	goalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid goal id"})
+	}
+
+	var req models.CreateGoalActionRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid JSON body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: err.Error()})
+	}
+
+	goal, err := h.service.CreateGoalAction(c.Request().Context(), auth.UserID(c), goalID, &req)
+	if err != nil {
+		return goalError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Data:    goal,
+	})
+}
+
+func (h *Handler) UpdateGoalAction(c echo.Context) error {
+	goalID, actionID, ok := goalActionIDs(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid goal or action id"})
+	}
+
+	var req models.UpdateGoalActionRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid JSON body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: err.Error()})
+	}
+
+	goal, err := h.service.UpdateGoalAction(c.Request().Context(), auth.UserID(c), goalID, actionID, &req)
+	if err != nil {
+		return goalError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    goal,
+	})
+}
+
+// CompleteGoalAction marks a micro-action done and returns the updated goal.
+func (h *Handler) CompleteGoalAction(c echo.Context) error {
+	goalID, actionID, ok := goalActionIDs(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid goal or action id"})
+	}
+
+	goal, err := h.service.CompleteGoalAction(c.Request().Context(), auth.UserID(c), goalID, actionID)
+	if err != nil {
+		return goalError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    goal,
+	})
+}
+
+func (h *Handler) DeleteGoalAction(c echo.Context) error {
+	goalID, actionID, ok := goalActionIDs(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid goal or action id"})
+	}
+
+	if err := h.service.DeleteGoalAction(c.Request().Context(), auth.UserID(c), goalID, actionID); err != nil {
+		return goalError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func goalActionIDs(c echo.Context) (goalID, actionID uuid.UUID, ok bool) {
+	goalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false
+	}
+	actionID, err = uuid.Parse(c.Param("actionId"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false
+	}
+	return goalID, actionID, true
+}
+
+func goalError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, models.ErrNotFound):
+		return c.JSON(http.StatusNotFound, Response{Success: false, Error: "goal or action not found"})
+	case errors.Is(err, services.ErrInvalidGoal):
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: err.Error()})
+	case errors.Is(err, services.ErrActionBlocked):
+		return c.JSON(http.StatusConflict, Response{Success: false, Error: err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, Response{Success: false, Error: err.Error()})
+	}
+}
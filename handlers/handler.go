@@ -1,21 +1,34 @@
 package handlers
 
 import (
+	"backend/auth"
+	"backend/llm"
 	"backend/models"
 	"backend/services"
+	"context"
+	"errors"
+	"log"
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
 )
 
 type Handler struct {
-	service services.Service
+	service  services.Service
+	hub      *Hub
+	upgrader websocket.Upgrader
 }
 
-func NewHandler(service services.Service) *Handler {
+// NewHandler serves the API; allowedOrigins are the browser origins, besides
+// the API's own, that may open WebSocket connections.
+func NewHandler(service services.Service, allowedOrigins []string) *Handler {
 	return &Handler{
-		service: service,
+		service:  service,
+		hub:      NewHub(),
+		upgrader: newUpgrader(allowedOrigins),
 	}
 }
 
@@ -24,12 +37,16 @@ type Response struct {
 	Message string      `json:"message,omitempty"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
+	Code    string      `json:"code,omitempty"` // stable machine-readable error code
+
+	// Session is set when the request started a guest session.
+	Session *models.AuthResponse `json:"session,omitempty"`
 }
 
 func (h *Handler) StartNewChat(c echo.Context) error {
 	chatID := uuid.New()
 
-	var req *models.LLMChatRequest
+	var req models.LLMChatRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, Response{
 			Success: false,
@@ -37,12 +54,41 @@ func (h *Handler) StartNewChat(c echo.Context) error {
 		})
 	}
 
-	userMessage := &models.Message{
-		ChatID:  chatID,
-		Role:    "user",
-		Content: req.Content,
+	// A new chat has no suggestions yet, so only content can open it.
+	userMessage, err := h.userMessage(c.Request().Context(), chatID, req.Content, req.SuggestionID)
+	if err != nil {
+		return chatError(c, err)
+	}
+
+	model, err := h.service.ResolveModel(req.Model)
+	if err != nil {
+		return chatError(c, err)
+	}
+
+	// Anonymous visitors get a guest session owning the chat.
+	userID := auth.UserID(c)
+	var session *models.AuthResponse
+	if userID == uuid.Nil {
+		guest, err := h.service.StartGuestSession(c.Request().Context())
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, Response{Success: false, Error: err.Error()})
+		}
+		session = guest
+		userID = guest.User.ID
+	}
+
+	opts := models.ReplyOptions{Structured: req.Structured}
+	chat, err := h.service.CreateNewChat(c.Request().Context(), userID, chatID, model, userMessage, opts)
+	if err != nil && session != nil {
+		// The client never sees the guest's token, so nobody could use it.
+		if derr := h.service.DiscardGuestSession(context.WithoutCancel(c.Request().Context()), userID); derr != nil {
+			log.Printf("Discard guest %s: %v", userID, derr)
+		}
+	}
+	var perr *llm.ProviderError
+	if errors.As(err, &perr) {
+		return providerError(c, perr)
 	}
-	chat, err := h.service.CreateNewChat(c.Request().Context(), chatID, userMessage)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]any{
 			"success": false, "error": err.Error(),
@@ -52,6 +98,7 @@ func (h *Handler) StartNewChat(c echo.Context) error {
 	return c.JSON(http.StatusOK, Response{
 		Success: true,
 		Data:    chat,
+		Session: session,
 	})
 }
 
@@ -64,7 +111,7 @@ func (h *Handler) LLMChat(c echo.Context) error {
 		})
 	}
 
-	var req *models.LLMChatRequest
+	var req models.LLMChatRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, Response{
 			Success: false,
@@ -72,24 +119,20 @@ func (h *Handler) LLMChat(c echo.Context) error {
 		})
 	}
 
-	fullChat, err := h.service.GetChatByID(c.Request().Context(), chatID)
+	fullChat, err := h.service.GetChatByID(c.Request().Context(), auth.UserID(c), chatID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]any{
-			"success": false, "error": err.Error(),
-		})
+		return chatError(c, err)
 	}
 
-	userMessage := &models.Message{
-		ChatID:  chatID,
-		Role:    "user",
-		Content: req.Content,
+	userMessage, err := h.userMessage(c.Request().Context(), chatID, req.Content, req.SuggestionID)
+	if err != nil {
+		return chatError(c, err)
 	}
 
-	responseMessage, err := h.service.LLMRequestAndSave(c.Request().Context(), userMessage, fullChat)
+	opts := models.ReplyOptions{Structured: req.Structured}
+	responseMessage, err := h.service.LLMRequestAndSave(c.Request().Context(), userMessage, fullChat, opts)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]any{
-			"success": false, "error": err.Error(),
-		})
+		return chatError(c, err)
 	}
 
 	return c.JSON(http.StatusOK, Response{
@@ -98,6 +141,35 @@ func (h *Handler) LLMChat(c echo.Context) error {
 	})
 }
 
+var errContentOrSuggestion = errors.New("send either content or suggestion_id")
+
+// userMessage builds the user's turn from typed content or from a tapped
+// suggestion, which is sent as its text and tagged with its ID.
+func (h *Handler) userMessage(ctx context.Context, chatID uuid.UUID, content string, suggestionID *uuid.UUID) (*models.Message, error) {
+	message := &models.Message{
+		ChatID:  chatID,
+		Role:    "user",
+		Content: content,
+	}
+	if suggestionID == nil {
+		if strings.TrimSpace(content) == "" {
+			return nil, errContentOrSuggestion
+		}
+		return message, nil
+	}
+	if strings.TrimSpace(content) != "" {
+		return nil, errContentOrSuggestion
+	}
+
+	text, err := h.service.SuggestionText(ctx, chatID, *suggestionID)
+	if err != nil {
+		return nil, err
+	}
+	message.Content = text
+	message.SuggestionID = suggestionID
+	return message, nil
+}
+
 func (h *Handler) GetChatByID(c echo.Context) error {
 	chatIDStr := c.Param("id")
 	chatID, err := uuid.Parse(chatIDStr)
@@ -107,11 +179,16 @@ func (h *Handler) GetChatByID(c echo.Context) error {
 		})
 	}
 
-	chat, err := h.service.GetChatByID(c.Request().Context(), chatID)
+	getChat := h.service.GetChatByID
+	if c.QueryParam("tree") == "true" {
+		getChat = h.service.GetChatTree
+	}
+	chat, err := getChat(c.Request().Context(), auth.UserID(c), chatID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]any{
-			"success": false, "error": err.Error(),
-		})
+		return chatError(c, err)
+	}
+	if c.QueryParam("include_summary") != "true" {
+		chat.Summary = nil
 	}
 
 	return c.JSON(http.StatusOK, map[string]any{
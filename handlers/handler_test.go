@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/llm"
+	"backend/models"
+	"backend/services"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// startService answers StartNewChat's calls and counts them. CreateNewChat
+// fails with fail when set.
+type startService struct {
+	services.Service
+	guests, chats int
+	fail          error
+	discarded     []uuid.UUID
+}
+
+func (s *startService) ResolveModel(name string) (string, error) {
+	return "gpt-4o-mini", nil
+}
+
+func (s *startService) StartGuestSession(ctx context.Context) (*models.AuthResponse, error) {
+	s.guests++
+	return &models.AuthResponse{User: &models.User{ID: uuid.New()}}, nil
+}
+
+func (s *startService) CreateNewChat(ctx context.Context, userID, chatID uuid.UUID, model string, req *models.Message, opts models.ReplyOptions) (*models.Chat, error) {
+	if s.fail != nil {
+		return nil, s.fail
+	}
+	s.chats++
+	return &models.Chat{ID: chatID, UserID: userID, Model: model}, nil
+}
+
+func (s *startService) DiscardGuestSession(ctx context.Context, guestID uuid.UUID) error {
+	s.discarded = append(s.discarded, guestID)
+	return nil
+}
+
+func post(handler echo.HandlerFunc, route, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	e.POST(route, handler)
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func postStart(h *Handler, body string) *httptest.ResponseRecorder {
+	return post(h.StartNewChat, "/start", "/start", body)
+}
+
+func (s *startService) SuggestionText(ctx context.Context, chatID, suggestionID uuid.UUID) (string, error) {
+	return "", services.ErrUnknownSuggestion
+}
+
+func TestStartNewChatRejectsBlankMessages(t *testing.T) {
+	tests := []struct{ name, body string }{
+		{"empty body", ""},
+		{"blank content", `{"content": "  \n"}`},
+		{"suggestion", `{"suggestion_id": "` + uuid.NewString() + `"}`},
+	}
+	for _, tt := range tests {
+		svc := &startService{}
+		rec := postStart(NewHandler(svc, nil), tt.body)
+		if rec.Code != http.StatusBadRequest || svc.guests != 0 || svc.chats != 0 {
+			t.Errorf("%s: status %d with %d guests and %d chats, want 400 and nothing created", tt.name, rec.Code, svc.guests, svc.chats)
+		}
+	}
+}
+
+func TestLLMChatEmptyBody(t *testing.T) {
+	h := NewHandler(&streamService{}, nil)
+	path := "/llm-prompt/" + uuid.NewString()
+	tests := []struct {
+		name    string
+		handler echo.HandlerFunc
+		route   string
+		path    string
+	}{
+		{"LLMChat", h.LLMChat, "/llm-prompt/:id", path},
+		{"LLMChatStream", h.LLMChatStream, "/llm-prompt/:id/stream", path + "/stream"},
+	}
+	for _, tt := range tests {
+		if rec := post(tt.handler, tt.route, tt.path, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", tt.name, rec.Code)
+		}
+	}
+}
+
+func TestStartNewChatDiscardsGuestOnFailure(t *testing.T) {
+	svc := &startService{fail: &llm.ProviderError{Kind: llm.KindOverloaded, Message: "down"}}
+	rec := postStart(NewHandler(svc, nil), `{"content": "Привет"}`)
+	if rec.Code == http.StatusOK {
+		t.Fatalf("status = 200, want the provider error")
+	}
+	if svc.guests != 1 || len(svc.discarded) != 1 {
+		t.Errorf("started %d guests and discarded %d, want the one guest discarded", svc.guests, len(svc.discarded))
+	}
+}
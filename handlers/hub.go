@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+
+	"backend/models"
+
+	"github.com/google/uuid"
+)
+
+// Hub tracks the WebSocket clients watching each chat so that a turn started
+// from one device is mirrored to every other open socket on the same chat.
+type Hub struct {
+	mu      sync.Mutex
+	clients map[uuid.UUID]map[*wsClient]struct{}
+	turns   map[uuid.UUID]context.CancelFunc // the turn in flight per chat
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		clients: make(map[uuid.UUID]map[*wsClient]struct{}),
+		turns:   make(map[uuid.UUID]context.CancelFunc),
+	}
+}
+
+func (h *Hub) register(c *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	set, ok := h.clients[c.chatID]
+	if !ok {
+		set = make(map[*wsClient]struct{})
+		h.clients[c.chatID] = set
+	}
+	set[c] = struct{}{}
+}
+
+func (h *Hub) unregister(c *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	set := h.clients[c.chatID]
+	delete(set, c)
+	if len(set) == 0 {
+		delete(h.clients, c.chatID)
+		// Nobody is left to receive the reply.
+		if cancel, ok := h.turns[c.chatID]; ok {
+			cancel()
+		}
+	}
+}
+
+// Broadcast queues the frame on every socket watching chatID. Slow clients
+// whose buffers are full are dropped rather than blocking the turn.
+func (h *Hub) Broadcast(chatID uuid.UUID, frameType string, data any) {
+	env := models.WSEnvelope{Type: frameType, ChatID: chatID, Data: data}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients[chatID] {
+		c.enqueue(env)
+	}
+}
+
+// acquire starts a turn on chatID and returns its context, which outlives the
+// socket that sent the message and is cancelled once the last socket on the
+// chat unregisters or after wsTurnTimeout. It reports false if another socket
+// already started a turn. The caller must release the chat when done.
+func (h *Hub) acquire(parent context.Context, chatID uuid.UUID) (context.Context, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, busy := h.turns[chatID]; busy {
+		return nil, false
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(parent), wsTurnTimeout)
+	h.turns[chatID] = cancel
+	return ctx, true
+}
+
+func (h *Hub) release(chatID uuid.UUID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if cancel, ok := h.turns[chatID]; ok {
+		cancel()
+		delete(h.turns, chatID)
+	}
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ListModels returns the models a chat can be started with.
+func (h *Handler) ListModels(c echo.Context) error {
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    h.service.ListModels(),
+	})
+}
+
+// LLMStats returns request and failure counters per provider/model pair of
+// the fallback chain since startup.
+func (h *Handler) LLMStats(c echo.Context) error {
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    h.service.LLMStats(),
+	})
+}
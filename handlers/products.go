@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"backend/models"
+	"backend/services"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ListProducts returns the whole catalog, inactive products included.
+func (h *Handler) ListProducts(c echo.Context) error {
+	products, err := h.service.ListProducts(c.Request().Context(), false)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    products,
+	})
+}
+
+func (h *Handler) GetProduct(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid product id"})
+	}
+
+	product, err := h.service.GetProduct(c.Request().Context(), id)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    product,
+	})
+}
+
+func (h *Handler) CreateProduct(c echo.Context) error {
+	var req models.ProductRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid JSON body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: err.Error()})
+	}
+
+	product, err := h.service.CreateProduct(c.Request().Context(), &req)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Data:    product,
+	})
+}
+
+// UpdateProduct replaces a product; omitted bounds are cleared.
+func (h *Handler) UpdateProduct(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid product id"})
+	}
+
+	var req models.ProductRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid JSON body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: err.Error()})
+	}
+
+	product, err := h.service.UpdateProduct(c.Request().Context(), id, &req)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    product,
+	})
+}
+
+func (h *Handler) DeleteProduct(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid product id"})
+	}
+
+	if err := h.service.DeleteProduct(c.Request().Context(), id); err != nil {
+		return productError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func productError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, models.ErrNotFound):
+		return c.JSON(http.StatusNotFound, Response{Success: false, Error: "product not found"})
+	case errors.Is(err, services.ErrProductExists):
+		return c.JSON(http.StatusConflict, Response{Success: false, Error: err.Error()})
+	case errors.Is(err, services.ErrInvalidProduct):
+		return c.JSON(http.StatusBadRequest, Response{Success: false, Error: err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, Response{Success: false, Error: err.Error()})
+	}
+}
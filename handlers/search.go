@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend/auth"
+	"backend/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Search looks through the caller's chats and messages.
+// Query: q, limit, cursor (from next_cursor of the previous page).
+func (h *Handler) Search(c echo.Context) error {
+	params := models.SearchParams{Query: c.QueryParam("q"), Cursor: c.QueryParam("cursor")}
+
+	if v := c.QueryParam("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return c.JSON(http.StatusBadRequest, Response{Success: false, Error: "invalid limit"})
+		}
+		params.Limit = limit
+	}
+
+	page, err := h.service.Search(c.Request().Context(), auth.UserID(c), params)
+	if err != nil {
+		return chatError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    page,
+	})
+}
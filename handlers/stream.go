@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"backend/auth"
+	"backend/llm"
+	"backend/models"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// LLMChatStream is the Server-Sent Events flavour of LLMChat. It emits a
+// "delta" event per content fragment, then either "done" with the saved
+// assistant message or "error".
+func (h *Handler) LLMChatStream(c echo.Context) error {
+	chatIDStr := c.Param("id")
+	chatID, err := uuid.Parse(chatIDStr)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			"success": false, "error": "invalid chat id",
+		})
+	}
+
+	var req models.LLMChatRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "invalid JSON body",
+		})
+	}
+
+	ctx := c.Request().Context()
+	fullChat, err := h.service.GetChatByID(ctx, auth.UserID(c), chatID)
+	if err != nil {
+		return chatError(c, err)
+	}
+
+	userMessage, err := h.userMessage(ctx, chatID, req.Content, req.SuggestionID)
+	if err != nil {
+		return chatError(c, err)
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	responseMessage, err := h.service.LLMStreamAndSave(ctx, userMessage, fullChat, func(delta string) error {
+		return writeSSE(res, "delta", map[string]string{"content": delta})
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			// Client disconnected, nobody is listening.
+			return nil
+		}
+		return writeSSE(res, "error", map[string]any{
+			"error": errorText(err), "code": llm.ErrorCode(err), "message": responseMessage,
+		})
+	}
+
+	return writeSSE(res, "done", responseMessage)
+}
+
+func writeSSE(res *echo.Response, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/auth"
+	"backend/llm"
+	"backend/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	wsWriteWait      = 10 * time.Second
+	wsPongWait       = 60 * time.Second
+	wsPingPeriod     = wsPongWait * 9 / 10
+	wsMaxMessageSize = 64 * 1024
+	wsSendBuffer     = 256
+	wsTurnTimeout    = 5 * time.Minute
+)
+
+func newUpgrader(allowedOrigins []string) websocket.Upgrader {
+	return websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     originChecker(allowedOrigins),
+	}
+}
+
+// originChecker accepts browser handshakes from the API's own origin and from
+// the allowed ones ("*" allows any). Requests without an Origin header come
+// from non-browser clients and are let through.
+func originChecker(allowed []string) func(*http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		if strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+		origin = strings.ToLower(origin)
+		return slices.ContainsFunc(allowed, func(a string) bool { return a == "*" || a == origin })
+	}
+}
+
+type wsClient struct {
+	userID    uuid.UUID
+	chatID    uuid.UUID
+	conn      *websocket.Conn
+	send      chan models.WSEnvelope
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newWSClient(userID, chatID uuid.UUID, conn *websocket.Conn) *wsClient {
+	return &wsClient{
+		userID: userID,
+		chatID: chatID,
+		conn:   conn,
+		send:   make(chan models.WSEnvelope, wsSendBuffer),
+		done:   make(chan struct{}),
+	}
+}
+
+func (c *wsClient) enqueue(env models.WSEnvelope) {
+	select {
+	case <-c.done:
+	case c.send <- env:
+	default:
+		c.close()
+	}
+}
+
+func (c *wsClient) sendError(msg string) {
+	c.enqueue(models.WSEnvelope{Type: models.WSTypeError, ChatID: c.chatID, Data: models.WSError{Error: msg}})
+}
+
+func (c *wsClient) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+func (c *wsClient) writePump() {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.close()
+	}()
+
+	for {
+		select {
+		case env := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteJSON(env); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// ChatWebSocket upgrades to a WebSocket bound to one chat. Clients send
+// {"type":"message","content":"..."} frames and receive models.WSEnvelope
+// frames: the persisted user and assistant messages, reply deltas, thinking
+// state, title updates and errors. Every socket open on the chat sees every
+// turn.
+func (h *Handler) ChatWebSocket(c echo.Context) error {
+	chatIDStr := c.Param("id")
+	chatID, err := uuid.Parse(chatIDStr)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			"success": false, "error": "invalid chat id",
+		})
+	}
+
+	userID := auth.UserID(c)
+	if _, err := h.service.GetChatByID(c.Request().Context(), userID, chatID); err != nil {
+		return chatError(c, err)
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		// Upgrade has already replied to the client.
+		return nil
+	}
+
+	// A hijacked connection does not cancel the request context when it
+	// drops, so the read loop owns the lifetime instead.
+	ctx, cancel := context.WithCancel(c.Request().Context())
+	defer cancel()
+
+	client := newWSClient(userID, chatID, conn)
+	h.hub.register(client)
+	defer h.hub.unregister(client)
+	defer client.close()
+
+	go client.writePump()
+
+	conn.SetReadLimit(wsMaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		var in models.WSIncoming
+		if err := conn.ReadJSON(&in); err != nil {
+			return nil
+		}
+
+		switch in.Type {
+		case models.WSTypeMessage:
+			if strings.TrimSpace(in.Content) == "" && in.SuggestionID == nil {
+				client.sendError("content is required")
+				continue
+			}
+			go h.runWSTurn(ctx, client, in.Content, in.SuggestionID)
+		default:
+			client.sendError("unknown frame type: " + in.Type)
+		}
+	}
+}
+
+// runWSTurn answers one message. The turn runs on a context owned by the hub
+// rather than the sending socket, so the other sockets on the chat still get
+// the reply when the sender disconnects.
+func (h *Handler) runWSTurn(socketCtx context.Context, client *wsClient, content string, suggestionID *uuid.UUID) {
+	chatID := client.chatID
+	ctx, ok := h.hub.acquire(socketCtx, chatID)
+	if !ok {
+		client.sendError("a reply is already being generated for this chat")
+		return
+	}
+	defer h.hub.release(chatID)
+
+	fullChat, err := h.service.GetChatByID(ctx, client.userID, chatID)
+	if err != nil {
+		client.sendError(err.Error())
+		return
+	}
+
+	userMessage, err := h.userMessage(ctx, chatID, content, suggestionID)
+	if err != nil {
+		client.sendError(err.Error())
+		return
+	}
+
+	// The user message is persisted before the first delta arrives, so that
+	// is the earliest point it can be mirrored with its ID.
+	var announced sync.Once
+	announceUser := func() {
+		announced.Do(func() {
+			if userMessage.ID != uuid.Nil {
+				h.hub.Broadcast(chatID, models.WSTypeMessage, userMessage)
+			}
+		})
+	}
+
+	h.hub.Broadcast(chatID, models.WSTypeThinking, models.WSThinking{Active: true})
+	responseMessage, err := h.service.LLMStreamAndSave(ctx, userMessage, fullChat, func(delta string) error {
+		announceUser()
+		h.hub.Broadcast(chatID, models.WSTypeDelta, models.WSDelta{Content: delta})
+		return nil
+	})
+	announceUser()
+	h.hub.Broadcast(chatID, models.WSTypeThinking, models.WSThinking{Active: false})
+
+	if responseMessage != nil {
+		h.hub.Broadcast(chatID, models.WSTypeMessage, responseMessage)
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			h.hub.Broadcast(chatID, models.WSTypeError, models.WSError{Error: errorText(err), Code: llm.ErrorCode(err)})
+		}
+		return
+	}
+
+	fullChat.Messages = append(fullChat.Messages, *userMessage, *responseMessage)
+	title, generated, err := h.service.EnsureTitle(ctx, fullChat)
+	if err != nil {
+		client.sendError("generate title: " + err.Error())
+		return
+	}
+	if generated {
+		h.hub.Broadcast(chatID, models.WSTypeTitle, models.WSTitle{Title: title})
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/llm"
+	"backend/models"
+	"backend/services"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+)
+
+func TestOriginChecker(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		origin  string
+		want    bool
+	}{
+		{"no origin header", nil, "", true},
+		{"same origin", nil, "http://api.example.com", true},
+		{"foreign origin", nil, "https://evil.example.net", false},
+		{"listed origin", []string{"http://localhost:3000"}, "http://localhost:3000", true},
+		{"listed origin, other case", []string{"http://localhost:3000"}, "HTTP://LocalHost:3000", true},
+		{"listed host on another port", []string{"http://localhost:3000"}, "http://localhost:3001", false},
+		{"any origin", []string{"*"}, "https://evil.example.net", true},
+		{"malformed origin", []string{"http://localhost:3000"}, "http://%zz", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "http://api.example.com/chats/x/ws", nil)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if got := originChecker(tt.allowed)(r); got != tt.want {
+			t.Errorf("%s: originChecker = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// streamService answers turns once release is closed, failing if the turn's
+// context is cancelled first.
+type streamService struct {
+	services.Service
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *streamService) GetChatByID(ctx context.Context, userID, chatID uuid.UUID) (*models.Chat, error) {
+	return &models.Chat{ID: chatID, UserID: userID}, nil
+}
+
+func (s *streamService) LLMStreamAndSave(ctx context.Context, message *models.Message, chat *models.Chat, onDelta llm.DeltaFunc) (*models.Message, error) {
+	close(s.started)
+	select {
+	case <-s.release:
+		return &models.Message{ID: uuid.New(), ChatID: chat.ID, Role: "assistant", Content: "Ответ"}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *streamService) EnsureTitle(ctx context.Context, chat *models.Chat) (string, bool, error) {
+	return "", false, nil
+}
+
+func (h *Hub) watchers(chatID uuid.UUID) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients[chatID])
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !cond(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+func TestTurnOutlivesSender(t *testing.T) {
+	svc := &streamService{started: make(chan struct{}), release: make(chan struct{})}
+	h := NewHandler(svc, nil)
+	e := echo.New()
+	e.GET("/chats/:id/ws", h.ChatWebSocket)
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	chatID := uuid.New()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/chats/" + chatID.String() + "/ws"
+	sender, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	watcher, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+	waitFor(t, "both sockets", func() bool { return h.hub.watchers(chatID) == 2 })
+
+	if err := sender.WriteJSON(models.WSIncoming{Type: models.WSTypeMessage, Content: "Вопрос"}); err != nil {
+		t.Fatal(err)
+	}
+	<-svc.started
+	sender.Close()
+	waitFor(t, "the sender to leave", func() bool { return h.hub.watchers(chatID) == 1 })
+	close(svc.release)
+
+	watcher.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var env struct {
+			Type string         `json:"type"`
+			Data models.Message `json:"data"`
+		}
+		if err := watcher.ReadJSON(&env); err != nil {
+			t.Fatalf("watcher got no reply: %v", err)
+		}
+		if env.Type == models.WSTypeError {
+			t.Fatalf("watcher got an error frame instead of the reply")
+		}
+		if env.Type == models.WSTypeMessage && env.Data.Role == "assistant" {
+			break
+		}
+	}
+}
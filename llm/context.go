@@ -0,0 +1,51 @@
+package llm
+
+import "errors"
+
+var ErrContextTooLong = errors.New("message does not fit into the model context window")
+
+// FitContext trims msgs so that their prompt size stays within budget tokens.
+// Leading system messages and the final message are always kept; older
+// messages in between are dropped oldest-first, and the kept history always
+// starts at a user message so no assistant reply is left without its question.
+// It returns the trimmed slice and the number of messages dropped.
+func (tc *TokenCounter) FitContext(model string, msgs []Message, budget int) ([]Message, int, error) {
+	if len(msgs) == 0 {
+		return msgs, 0, nil
+	}
+
+	head := 0
+	for head < len(msgs)-1 && msgs[head].Role == "system" {
+		head++
+	}
+	last := msgs[len(msgs)-1]
+	body := msgs[head : len(msgs)-1]
+
+	used := tc.CountMessages(model, msgs[:head]) + tc.countMessage(model, last)
+	if used > budget {
+		return nil, len(msgs), ErrContextTooLong
+	}
+
+	// Walk back from the newest message while the budget allows.
+	start := len(body)
+	for start > 0 {
+		cost := tc.countMessage(model, body[start-1])
+		if used+cost > budget {
+			break
+		}
+		used += cost
+		start--
+	}
+	if start == 0 {
+		return msgs, 0, nil
+	}
+	for start < len(body) && body[start].Role != "user" {
+		start++
+	}
+
+	out := make([]Message, 0, head+len(body)-start+1)
+	out = append(out, msgs[:head]...)
+	out = append(out, body[start:]...)
+	out = append(out, last)
+	return out, start, nil
+}
@@ -0,0 +1,107 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Provider error kinds. They are stable and safe to show to clients.
+const (
+	KindRateLimited = "rate_limited" // 429, retry after a while
+	KindOverloaded  = "overloaded"   // 5xx or the provider could not be reached
+	KindBadRequest  = "bad_request"  // the provider rejected our request
+	KindAuth        = "auth"         // missing or invalid API key
+	KindUnavailable = "unavailable"  // circuit breaker is open, not even tried
+)
+
+// ErrCircuitOpen is wrapped by the KindUnavailable errors of the breaker.
+var ErrCircuitOpen = errors.New("llm provider circuit is open")
+
+// ProviderError is a failed call to the LLM provider.
+type ProviderError struct {
+	Kind       string
+	StatusCode int           // HTTP status, 0 if there was no response
+	RetryAfter time.Duration // from the Retry-After header, 0 if absent
+	Message    string        // response body or transport error, for logs
+	Err        error
+}
+
+func (e *ProviderError) Error() string {
+	msg := "llm provider error (" + e.Kind + ")"
+	if e.StatusCode != 0 {
+		msg += fmt.Sprintf(": status %d", e.StatusCode)
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+func (e *ProviderError) Unwrap() error { return e.Err }
+
+// Retryable reports whether the same request may succeed later.
+func (e *ProviderError) Retryable() bool {
+	return e.Kind == KindRateLimited || e.Kind == KindOverloaded
+}
+
+// ErrorCode is the stable code of a provider failure, such as
+// "llm_rate_limited", or "" if err is not one.
+func ErrorCode(err error) string {
+	var perr *ProviderError
+	if !errors.As(err, &perr) {
+		return ""
+	}
+	return "llm_" + perr.Kind
+}
+
+// statusError classifies a non-200 response.
+func statusError(resp *http.Response, body []byte) *ProviderError {
+	e := &ProviderError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		Message:    strings.TrimSpace(string(body)),
+	}
+	switch code := resp.StatusCode; {
+	case code == http.StatusTooManyRequests:
+		e.Kind = KindRateLimited
+	case code == http.StatusUnauthorized, code == http.StatusForbidden:
+		e.Kind = KindAuth
+	case code == http.StatusRequestTimeout, code >= 500:
+		e.Kind = KindOverloaded
+	default:
+		e.Kind = KindBadRequest
+	}
+	return e
+}
+
+// transportError wraps a failed round trip. Cancellation by the caller is
+// returned as is: it is not the provider's fault.
+func transportError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return &ProviderError{Kind: KindOverloaded, Message: err.Error(), Err: err}
+}
+
+// parseRetryAfter reads delay-seconds or an HTTP date.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,56 @@
+package llm
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"0", 0},
+		{"7", 7 * time.Second},
+		{" 120 ", 2 * time.Minute},
+		{"-5", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"Sun, 06 Nov 1994 08:49:37 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.header, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestStatusErrorKinds(t *testing.T) {
+	tests := []struct {
+		status int
+		kind   string
+	}{
+		{http.StatusTooManyRequests, KindRateLimited},
+		{http.StatusUnauthorized, KindAuth},
+		{http.StatusForbidden, KindAuth},
+		{http.StatusRequestTimeout, KindOverloaded},
+		{http.StatusInternalServerError, KindOverloaded},
+		{http.StatusServiceUnavailable, KindOverloaded},
+		{http.StatusBadRequest, KindBadRequest},
+		{http.StatusNotFound, KindBadRequest},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Header: http.Header{"Retry-After": {"3"}}}
+		e := statusError(resp, []byte(" body \n"))
+		if e.Kind != tt.kind {
+			t.Errorf("status %d: kind %q, want %q", tt.status, e.Kind, tt.kind)
+		}
+		if e.RetryAfter != 3*time.Second || e.Message != "body" {
+			t.Errorf("status %d: RetryAfter %v, Message %q", tt.status, e.RetryAfter, e.Message)
+		}
+	}
+}
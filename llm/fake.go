@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// Fake is an in-process Provider for tests and local development. It replays
+// queued responses in order and records every request it receives. When the
+// queue is empty it falls back to Reply, or echoes the last message.
+type Fake struct {
+	mu        sync.Mutex
+	responses []fakeResult
+	requests  []ChatRequest
+
+	Reply func(req *ChatRequest) (*ChatResponse, error)
+}
+
+type fakeResult struct {
+	resp *ChatResponse
+	err  error
+}
+
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+// Push queues an assistant reply with the given content.
+func (f *Fake) Push(content string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses = append(f.responses, fakeResult{resp: &ChatResponse{
+		Message: Message{Role: "assistant", Content: content},
+	}})
+}
+
+// PushToolCalls queues an assistant reply that calls the given tools.
+func (f *Fake) PushToolCalls(calls ...ToolCall) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses = append(f.responses, fakeResult{resp: &ChatResponse{
+		Message: Message{Role: "assistant", ToolCalls: calls},
+	}})
+}
+
+// PushError queues an error to be returned by the next call.
+func (f *Fake) PushError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses = append(f.responses, fakeResult{err: err})
+}
+
+// Requests returns a copy of every request received so far.
+func (f *Fake) Requests() []ChatRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]ChatRequest, len(f.requests))
+	copy(out, f.requests)
+	return out
+}
+
+func (f *Fake) ChatCompletion(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	f.requests = append(f.requests, cloneRequest(req))
+	var next *fakeResult
+	if len(f.responses) > 0 {
+		next = &f.responses[0]
+		f.responses = f.responses[1:]
+	}
+	reply := f.Reply
+	f.mu.Unlock()
+
+	if next != nil {
+		return next.resp, next.err
+	}
+	if reply != nil {
+		return reply(req)
+	}
+	if len(req.Messages) == 0 {
+		return nil, errors.New("fake: empty request")
+	}
+	last := req.Messages[len(req.Messages)-1]
+	return &ChatResponse{Message: Message{Role: "assistant", Content: last.Content}}, nil
+}
+
+func cloneRequest(req *ChatRequest) ChatRequest {
+	c := *req
+	c.Messages = append([]Message(nil), req.Messages...)
+	c.Tools = append([]Tool(nil), req.Tools...)
+	return c
+}
+
+// ChatCompletionStream resolves the reply like ChatCompletion and emits it
+// word by word.
+func (f *Fake) ChatCompletionStream(ctx context.Context, req *ChatRequest, onDelta DeltaFunc) (*ChatResponse, error) {
+	resp, err := f.ChatCompletion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &ChatResponse{Message: Message{Role: resp.Message.Role, ToolCalls: resp.Message.ToolCalls}, Usage: resp.Usage}
+	var content strings.Builder
+	for _, word := range strings.SplitAfter(resp.Message.Content, " ") {
+		if word == "" {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			out.Message.Content = content.String()
+			return out, err
+		}
+		content.WriteString(word)
+		if err := onDelta(word); err != nil {
+			out.Message.Content = content.String()
+			return out, err
+		}
+	}
+	out.Message.Content = content.String()
+	return out, nil
+}
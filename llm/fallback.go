@@ -0,0 +1,202 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Target is one link of a fallback chain: a provider and the model to ask it
+// for. An empty Model keeps the model of the request.
+type Target struct {
+	Name     string // provider name as configured, for logs and stats
+	Model    string
+	Provider Provider
+}
+
+func (t Target) label(model string) string {
+	return t.Name + "/" + model
+}
+
+// ModelStats counts the calls made to one provider/model pair.
+type ModelStats struct {
+	Target        string    `json:"target"` // "provider/model"
+	Requests      int64     `json:"requests"`
+	Failures      int64     `json:"failures"`
+	Fallbacks     int64     `json:"fallbacks"` // failures that passed the request on to the next target
+	LastError     string    `json:"last_error,omitempty"`
+	LastFailureAt time.Time `json:"last_failure_at,omitzero"`
+}
+
+// Capabilities are the optional request features a model supports.
+type Capabilities struct {
+	Tools    bool
+	JSONMode bool
+}
+
+// Fallback tries its targets in order and answers with the first that does
+// not fail on the provider side. Bad requests are not retried elsewhere: the
+// next provider would reject them too. A request for a model with a route is
+// sent to that target first, ahead of the chain. Features a target's model
+// lacks are removed from the request sent to it.
+type Fallback struct {
+	targets []Target
+	routes  map[string]Target       // by model name
+	caps    map[string]Capabilities // by model name; unknown models get the request as is
+
+	mu    sync.Mutex
+	stats map[string]*ModelStats
+	order []string
+}
+
+func NewFallback(targets []Target, routes map[string]Target, caps map[string]Capabilities) *Fallback {
+	return &Fallback{targets: targets, routes: routes, caps: caps, stats: make(map[string]*ModelStats)}
+}
+
+// chain returns the targets to try for model, with the model filled in.
+func (f *Fallback) chain(model string) []Target {
+	out := make([]Target, 0, len(f.targets)+1)
+	if t, ok := f.routes[model]; ok {
+		out = append(out, t)
+	}
+	for _, t := range f.targets {
+		if t.Model == "" {
+			t.Model = model
+		}
+		if len(out) > 0 && out[0].Name == t.Name && out[0].Model == t.Model {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+func (f *Fallback) ChatCompletion(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	return f.do(ctx, req, func(p Provider, req *ChatRequest) (*ChatResponse, error) {
+		return p.ChatCompletion(ctx, req)
+	})
+}
+
+// ChatCompletionStream falls back only while nothing has been streamed yet.
+func (f *Fallback) ChatCompletionStream(ctx context.Context, req *ChatRequest, onDelta DeltaFunc) (*ChatResponse, error) {
+	return f.do(ctx, req, func(p Provider, req *ChatRequest) (*ChatResponse, error) {
+		streamed := false
+		resp, err := p.ChatCompletionStream(ctx, req, func(delta string) error {
+			streamed = true
+			return onDelta(delta)
+		})
+		if err != nil && streamed {
+			return resp, &finalError{err}
+		}
+		return resp, err
+	})
+}
+
+func (f *Fallback) do(ctx context.Context, req *ChatRequest, call func(Provider, *ChatRequest) (*ChatResponse, error)) (*ChatResponse, error) {
+	type try struct {
+		target Target
+		req    ChatRequest
+	}
+	var tries []try
+	for _, t := range f.chain(req.Model) {
+		attempt := *req
+		attempt.Model = t.Model
+		if f.adapt(&attempt) {
+			tries = append(tries, try{t, attempt})
+		}
+	}
+	if len(tries) == 0 {
+		return nil, &ProviderError{Kind: KindBadRequest, Message: "no configured model supports the request for " + req.Model}
+	}
+
+	var lastErr error
+	for i, tr := range tries {
+		t, attempt := tr.target, tr.req
+		label := t.label(attempt.Model)
+
+		resp, err := call(t.Provider, &attempt)
+		var final *finalError
+		if errors.As(err, &final) {
+			err = final.err
+		}
+		last := i == len(tries)-1 || final != nil || !shouldFallBack(ctx, err)
+		f.record(label, err, !last)
+		if err == nil || last {
+			if resp != nil {
+				resp.Model = attempt.Model
+			}
+			return resp, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// adapt drops what req.Model does not support. A JSON schema only shapes the
+// answer and tools are optional, but a conversation already holding tool
+// calls cannot go to a model without tools: adapt reports false then.
+func (f *Fallback) adapt(req *ChatRequest) bool {
+	caps, ok := f.caps[req.Model]
+	if !ok {
+		return true
+	}
+	if req.ResponseFormat != nil && !caps.JSONMode {
+		req.ResponseFormat = nil
+	}
+	if len(req.Tools) > 0 && !caps.Tools {
+		for _, m := range req.Messages {
+			if m.Role == "tool" || len(m.ToolCalls) > 0 {
+				return false
+			}
+		}
+		req.Tools = nil
+		req.ToolChoice = ""
+	}
+	return true
+}
+
+// shouldFallBack reports whether another provider may succeed where err
+// failed: timeouts, 5xx, rate limits and open circuits.
+func shouldFallBack(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var perr *ProviderError
+	return errors.As(err, &perr) && (perr.Retryable() || perr.Kind == KindUnavailable)
+}
+
+func (f *Fallback) record(label string, err error, fellBack bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	st, ok := f.stats[label]
+	if !ok {
+		st = &ModelStats{Target: label}
+		f.stats[label] = st
+		f.order = append(f.order, label)
+	}
+	st.Requests++
+	if err == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	st.Failures++
+	st.LastError = err.Error()
+	st.LastFailureAt = time.Now()
+	if fellBack {
+		st.Fallbacks++
+	}
+}
+
+// Stats returns the counters of every target called so far, in the order
+// they were first used.
+func (f *Fallback) Stats() []ModelStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out := make([]ModelStats, 0, len(f.order))
+	for _, label := range f.order {
+		out = append(out, *f.stats[label])
+	}
+	return out
+}
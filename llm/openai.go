@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAI talks to any endpoint implementing the OpenAI chat completions API.
+type OpenAI struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewOpenAI builds a client whose non-streaming calls give up after timeout.
+func NewOpenAI(baseURL, apiKey string, timeout time.Duration) *OpenAI {
+	return &OpenAI{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *OpenAI) ChatCompletion(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, transportError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, transportError(ctx, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp, body)
+	}
+
+	var llmResponse struct {
+		Choices []struct {
+			Message Message `json:"message"`
+		} `json:"choices"`
+		Usage Usage `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &llmResponse); err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+
+	if len(llmResponse.Choices) == 0 {
+		return nil, errors.New("llm response has no choices")
+	}
+
+	return &ChatResponse{
+		Message: llmResponse.Choices[0].Message,
+		Usage:   llmResponse.Usage,
+	}, nil
+}
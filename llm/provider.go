@@ -0,0 +1,182 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"backend/config"
+)
+
+// Provider is a chat completion backend. Implementations must be safe for
+// concurrent use.
+type Provider interface {
+	ChatCompletion(ctx context.Context, req *ChatRequest) (*ChatResponse, error)
+	// ChatCompletionStream calls onDelta for every content fragment as it
+	// arrives and returns the assembled message once the stream ends. If the
+	// stream is interrupted the partial message is returned with the error.
+	ChatCompletionStream(ctx context.Context, req *ChatRequest, onDelta DeltaFunc) (*ChatResponse, error)
+}
+
+// DeltaFunc receives streamed content fragments. Returning an error aborts the
+// stream.
+type DeltaFunc func(delta string) error
+
+type ChatRequest struct {
+	Model          string          `json:"model"`
+	Messages       []Message       `json:"messages"`
+	Temperature    *float64        `json:"temperature,omitempty"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	Tools          []Tool          `json:"tools,omitempty"`
+	ToolChoice     string          `json:"tool_choice,omitempty"` // ToolChoice*
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	Stream         bool            `json:"stream,omitempty"`
+	StreamOptions  *StreamOptions  `json:"stream_options,omitempty"`
+}
+
+const (
+	ToolChoiceAuto = "auto"
+	ToolChoiceNone = "none" // tools stay declared but the model must answer in text
+)
+
+// ResponseFormat asks the model for JSON matching a schema.
+type ResponseFormat struct {
+	Type       string      `json:"type"` // "json_schema"
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+type JSONSchema struct {
+	Name   string          `json:"name"`
+	Strict bool            `json:"strict"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+// NewJSONSchemaFormat builds a strict json_schema response format.
+func NewJSONSchemaFormat(name, schema string) *ResponseFormat {
+	return &ResponseFormat{
+		Type:       "json_schema",
+		JSONSchema: &JSONSchema{Name: name, Strict: true, Schema: json.RawMessage(schema)},
+	}
+}
+
+// Tool declares a function the model may call. Parameters is a JSON Schema
+// object.
+type Tool struct {
+	Type     string       `json:"type"` // always "function"
+	Function ToolFunction `json:"function"`
+}
+
+type ToolFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+// NewTool builds a function tool declaration.
+func NewTool(name, description, parameters string) Tool {
+	return Tool{
+		Type:     "function",
+		Function: ToolFunction{Name: name, Description: description, Parameters: json.RawMessage(parameters)},
+	}
+}
+
+type ToolCall struct {
+	ID       string           `json:"id"`
+	Type     string           `json:"type"`
+	Function ToolCallFunction `json:"function"`
+}
+
+type ToolCallFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"` // JSON encoded, as produced by the model
+}
+
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// Message is a chat message. Assistant messages may carry ToolCalls instead of
+// content; the results go back as "tool" messages referencing ToolCallID.
+type Message struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type ChatResponse struct {
+	Message Message `json:"message"`
+	Usage   Usage   `json:"usage"`
+	Model   string  `json:"model,omitempty"` // model that answered, set by Fallback
+}
+
+// NewProvider builds the fallback chain of cfg.LLM.Fallbacks, with every
+// registered model routed to its own provider first. Targets on the same
+// provider share its client and circuit breaker.
+func NewProvider(cfg *config.Config) (Provider, error) {
+	providers := make(map[string]Provider)
+	named := func(name string) (Provider, error) {
+		if p, ok := providers[name]; ok {
+			return p, nil
+		}
+		p, err := newNamedProvider(cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		providers[name] = p
+		return p, nil
+	}
+
+	targets := make([]Target, 0, len(cfg.LLM.Fallbacks))
+	for _, t := range cfg.LLM.Fallbacks {
+		p, err := named(t.Provider)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, Target{Name: t.Provider, Model: t.Model, Provider: p})
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("no llm providers configured")
+	}
+
+	routes := make(map[string]Target, len(cfg.LLM.Models))
+	caps := make(map[string]Capabilities, len(cfg.LLM.Models))
+	for _, m := range cfg.LLM.Models {
+		p, err := named(m.Provider)
+		if err != nil {
+			return nil, fmt.Errorf("model %s: %w", m.Name, err)
+		}
+		routes[m.Name] = Target{Name: m.Provider, Model: m.Name, Provider: p}
+		caps[m.Name] = Capabilities{Tools: m.Tools, JSONMode: m.JSONMode}
+	}
+	return NewFallback(targets, routes, caps), nil
+}
+
+// newNamedProvider builds a single provider: "fake", "openai" for the primary
+// endpoint, or any name listed in cfg.LLM.Endpoints.
+func newNamedProvider(cfg *config.Config, name string) (Provider, error) {
+	if name == "fake" {
+		return NewFake(), nil
+	}
+	endpoint := config.LLMEndpoint{BaseURL: cfg.LLM.BaseURL, APIKey: cfg.LLM.APIKey}
+	if name != "openai" {
+		var ok bool
+		if endpoint, ok = cfg.LLM.Endpoints[name]; !ok {
+			return nil, fmt.Errorf("unknown llm provider %q", name)
+		}
+	}
+	retry := RetryPolicy{
+		MaxRetries: cfg.LLM.MaxRetries,
+		BaseDelay:  cfg.LLM.RetryBaseDelay,
+		MaxDelay:   cfg.LLM.RetryMaxDelay,
+	}
+	breaker := NewBreaker(cfg.LLM.BreakerThreshold, cfg.LLM.BreakerCooldown)
+	return NewResilient(NewOpenAI(endpoint.BaseURL, endpoint.APIKey, cfg.LLM.Timeout), retry, breaker), nil
+}
@@ -0,0 +1,172 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// RetryPolicy controls retries of rate-limited and overloaded calls.
+type RetryPolicy struct {
+	MaxRetries int           // retries after the first attempt
+	BaseDelay  time.Duration // doubled on every retry, with jitter
+	MaxDelay   time.Duration // cap on a single wait; a longer Retry-After is not waited out
+}
+
+// backoff returns the wait before retry number attempt (0-based): an
+// exponential delay with equal jitter, or retryAfter if the provider asked
+// for longer.
+func (p RetryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	d := p.BaseDelay << attempt
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	d = d/2 + rand.N(d/2+1)
+	return max(d, retryAfter)
+}
+
+// Breaker is a circuit breaker. After Threshold consecutive provider failures
+// it opens and rejects calls for Cooldown; then a single probe call is let
+// through, closing the circuit on success and reopening it on failure. A
+// threshold of 0 disables it.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// allow reports whether a call may go ahead. A rejected call gets a
+// KindUnavailable error carrying the remaining cooldown.
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return nil
+	}
+	now := b.now()
+	if now.Before(b.openUntil) || b.probing {
+		return &ProviderError{
+			Kind:       KindUnavailable,
+			RetryAfter: max(b.openUntil.Sub(now), 0),
+			Message:    "circuit open",
+			Err:        ErrCircuitOpen,
+		}
+	}
+	b.probing = true
+	return nil
+}
+
+// record updates the breaker with the outcome of an allowed call. Only
+// provider-side failures count; bad requests and cancellations do not say
+// anything about the provider's health.
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	var perr *ProviderError
+	switch {
+	case err == nil:
+		b.failures = 0
+	case errors.As(err, &perr) && perr.Kind == KindOverloaded:
+		b.failures++
+		if b.failures >= b.threshold {
+			b.openUntil = b.now().Add(b.cooldown)
+		}
+	}
+}
+
+// Resilient wraps a Provider with retries and a circuit breaker.
+type Resilient struct {
+	next    Provider
+	retry   RetryPolicy
+	breaker *Breaker
+}
+
+func NewResilient(next Provider, retry RetryPolicy, breaker *Breaker) *Resilient {
+	return &Resilient{next: next, retry: retry, breaker: breaker}
+}
+
+func (r *Resilient) ChatCompletion(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	var resp *ChatResponse
+	err := r.do(ctx, func() error {
+		var err error
+		resp, err = r.next.ChatCompletion(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// ChatCompletionStream retries only while nothing has been streamed yet: once
+// the client has seen a delta, starting over would duplicate the text.
+func (r *Resilient) ChatCompletionStream(ctx context.Context, req *ChatRequest, onDelta DeltaFunc) (*ChatResponse, error) {
+	var resp *ChatResponse
+	streamed := false
+	err := r.do(ctx, func() error {
+		var err error
+		resp, err = r.next.ChatCompletionStream(ctx, req, func(delta string) error {
+			streamed = true
+			return onDelta(delta)
+		})
+		if err != nil && streamed {
+			return &finalError{err}
+		}
+		return err
+	})
+	return resp, err
+}
+
+// finalError marks an error that must not be retried.
+type finalError struct{ err error }
+
+func (e *finalError) Error() string { return e.err.Error() }
+func (e *finalError) Unwrap() error { return e.err }
+
+// do runs call with retries. The breaker records the outcome of the whole
+// call, not of each attempt: one failing call counts once however often it
+// was retried.
+func (r *Resilient) do(ctx context.Context, call func() error) error {
+	if err := r.breaker.allow(); err != nil {
+		return err
+	}
+	err := r.retrying(ctx, call)
+	r.breaker.record(err)
+
+	var final *finalError
+	if errors.As(err, &final) {
+		return final.err
+	}
+	return err
+}
+
+func (r *Resilient) retrying(ctx context.Context, call func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := call()
+
+		var perr *ProviderError
+		if errors.As(err, new(*finalError)) || !errors.As(err, &perr) || !perr.Retryable() ||
+			attempt >= r.retry.MaxRetries || perr.RetryAfter > r.retry.MaxDelay {
+			return err
+		}
+
+		timer := time.NewTimer(r.retry.backoff(attempt, perr.RetryAfter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
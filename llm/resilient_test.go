@@ -0,0 +1,148 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{MaxRetries: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter time.Duration
+		min, max   time.Duration
+	}{
+		{"first retry", 0, 0, 50 * time.Millisecond, 100 * time.Millisecond},
+		{"doubles", 2, 0, 200 * time.Millisecond, 400 * time.Millisecond},
+		{"capped at MaxDelay", 4, 0, 500 * time.Millisecond, time.Second},
+		{"shift overflow is capped", 70, 0, 500 * time.Millisecond, time.Second},
+		{"Retry-After wins when longer", 0, 3 * time.Second, 3 * time.Second, 3 * time.Second},
+		{"Retry-After shorter than backoff", 2, 10 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 100 {
+				d := p.backoff(tt.attempt, tt.retryAfter)
+				if d < tt.min || d > tt.max {
+					t.Fatalf("backoff(%d, %v) = %v, want within [%v, %v]", tt.attempt, tt.retryAfter, d, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func overloaded() error { return &ProviderError{Kind: KindOverloaded, StatusCode: 503} }
+
+func TestBreakerTransitions(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := NewBreaker(2, 30*time.Second)
+	b.now = func() time.Time { return now }
+
+	// Closed: failures below the threshold still let calls through.
+	if err := b.allow(); err != nil {
+		t.Fatalf("closed breaker rejected a call: %v", err)
+	}
+	b.record(overloaded())
+	if err := b.allow(); err != nil {
+		t.Fatalf("breaker opened below the threshold: %v", err)
+	}
+	b.record(overloaded())
+
+	// Open: rejected with the remaining cooldown.
+	err := b.allow()
+	var perr *ProviderError
+	if !errors.As(err, &perr) || perr.Kind != KindUnavailable || !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("open breaker returned %v, want an unavailable error", err)
+	}
+	if perr.RetryAfter != 30*time.Second {
+		t.Errorf("RetryAfter = %v, want 30s", perr.RetryAfter)
+	}
+
+	// Half-open: after the cooldown exactly one probe goes through.
+	now = now.Add(30 * time.Second)
+	if err := b.allow(); err != nil {
+		t.Fatalf("probe after cooldown rejected: %v", err)
+	}
+	if err := b.allow(); err == nil {
+		t.Fatal("second call allowed while the probe is in flight")
+	}
+
+	// A failed probe reopens the circuit for another cooldown.
+	b.record(overloaded())
+	if err := b.allow(); err == nil {
+		t.Fatal("breaker closed after a failed probe")
+	}
+	now = now.Add(30 * time.Second)
+	if err := b.allow(); err != nil {
+		t.Fatalf("second probe rejected: %v", err)
+	}
+
+	// A successful probe closes it.
+	b.record(nil)
+	for range 3 {
+		if err := b.allow(); err != nil {
+			t.Fatalf("closed breaker rejected a call: %v", err)
+		}
+	}
+}
+
+func TestBreakerIgnoresClientErrors(t *testing.T) {
+	b := NewBreaker(1, time.Minute)
+	for _, err := range []error{
+		&ProviderError{Kind: KindBadRequest, StatusCode: 400},
+		&ProviderError{Kind: KindRateLimited, StatusCode: 429},
+		context.Canceled,
+	} {
+		b.record(err)
+	}
+	if err := b.allow(); err != nil {
+		t.Fatalf("breaker opened on errors that say nothing about the provider: %v", err)
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b := NewBreaker(0, time.Minute)
+	for range 10 {
+		b.record(overloaded())
+	}
+	if err := b.allow(); err != nil {
+		t.Fatalf("disabled breaker rejected a call: %v", err)
+	}
+}
+
+func TestResilientRecordsOncePerCall(t *testing.T) {
+	fake := NewFake()
+	for range 3 {
+		fake.PushError(overloaded())
+	}
+	breaker := NewBreaker(2, time.Minute)
+	r := NewResilient(fake, RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}, breaker)
+
+	if _, err := r.ChatCompletion(context.Background(), &ChatRequest{Model: "m"}); err == nil {
+		t.Fatal("expected the call to fail after its retries")
+	}
+	if n := len(fake.Requests()); n != 3 {
+		t.Fatalf("provider called %d times, want 3", n)
+	}
+	if breaker.failures != 1 {
+		t.Fatalf("breaker counted %d failures for one call, want 1", breaker.failures)
+	}
+	if err := breaker.allow(); err != nil {
+		t.Fatalf("one failed call opened a breaker with threshold 2: %v", err)
+	}
+}
+
+func TestResilientRetriesUntilSuccess(t *testing.T) {
+	fake := NewFake()
+	fake.PushError(&ProviderError{Kind: KindRateLimited, StatusCode: 429})
+	fake.Push("ok")
+	r := NewResilient(fake, RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}, NewBreaker(1, time.Minute))
+
+	resp, err := r.ChatCompletion(context.Background(), &ChatRequest{Model: "m"})
+	if err != nil || resp.Message.Content != "ok" {
+		t.Fatalf("got %v, %v; want the reply after one retry", resp, err)
+	}
+}
@@ -0,0 +1,135 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type streamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Role      string          `json:"role"`
+			Content   string          `json:"content"`
+			ToolCalls []toolCallDelta `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *Usage `json:"usage"`
+}
+
+// toolCallDelta is a fragment of a streamed tool call. The id and name come
+// in the first fragment of each index, the arguments are spread over many.
+type toolCallDelta struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+func appendToolCallDelta(calls []ToolCall, d toolCallDelta) []ToolCall {
+	for len(calls) <= d.Index {
+		calls = append(calls, ToolCall{Type: "function"})
+	}
+	call := &calls[d.Index]
+	if d.ID != "" {
+		call.ID = d.ID
+	}
+	if d.Type != "" {
+		call.Type = d.Type
+	}
+	call.Function.Name += d.Function.Name
+	call.Function.Arguments += d.Function.Arguments
+	return calls
+}
+
+func (p *OpenAI) ChatCompletionStream(ctx context.Context, req *ChatRequest, onDelta DeltaFunc) (*ChatResponse, error) {
+	streamReq := *req
+	streamReq.Stream = true
+	streamReq.StreamOptions = &StreamOptions{IncludeUsage: true}
+
+	jsonData, err := json.Marshal(&streamReq)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	// The client timeout would cut long answers short, so streaming relies on
+	// ctx for cancellation instead.
+	client := &http.Client{Transport: p.client.Transport}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, transportError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, statusError(resp, body)
+	}
+
+	out := &ChatResponse{Message: Message{Role: "assistant"}}
+	var content strings.Builder
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			out.Message.Content = content.String()
+			return out, fmt.Errorf("parse stream chunk: %w", err)
+		}
+		if chunk.Usage != nil {
+			out.Usage = *chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Role != "" {
+				out.Message.Role = choice.Delta.Role
+			}
+			for _, d := range choice.Delta.ToolCalls {
+				out.Message.ToolCalls = appendToolCallDelta(out.Message.ToolCalls, d)
+			}
+			if choice.Delta.Content == "" {
+				continue
+			}
+			content.WriteString(choice.Delta.Content)
+			if err := onDelta(choice.Delta.Content); err != nil {
+				out.Message.Content = content.String()
+				return out, err
+			}
+		}
+	}
+	out.Message.Content = content.String()
+	if err := scanner.Err(); err != nil {
+		return out, fmt.Errorf("read stream: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return out, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"log"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+// The BPE tables are embedded: the default loader downloads them on first
+// use, which fails without network access and stalls the first requests.
+func init() {
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+// Per-message framing overhead of the chat format, see
+// https://github.com/openai/openai-cookbook (How to count tokens with tiktoken).
+const (
+	tokensPerMessage = 4
+	tokensReplyPrime = 3
+)
+
+// TokenCounter counts tokens with the tokenizer of the target model. The
+// tokenizer is built on first use per model; if that fails it falls back to a
+// conservative character-based estimate and tries again next time.
+type TokenCounter struct {
+	mu       sync.Mutex
+	encoders map[string]*tiktoken.Tiktoken
+}
+
+func NewTokenCounter() *TokenCounter {
+	return &TokenCounter{encoders: make(map[string]*tiktoken.Tiktoken)}
+}
+
+func (tc *TokenCounter) encoder(model string) *tiktoken.Tiktoken {
+	tc.mu.Lock()
+	enc, ok := tc.encoders[model]
+	tc.mu.Unlock()
+	if ok {
+		return enc
+	}
+
+	// Built outside the lock: parsing the tables takes a while, and other
+	// models should not wait for it. Two callers may race to build the same
+	// encoder; both results are equivalent.
+	enc, err := tiktoken.EncodingForModel(model)
+	if err != nil {
+		enc, err = tiktoken.GetEncoding("cl100k_base")
+	}
+	if err != nil {
+		log.Printf("Tokenizer for %s unavailable, estimating token counts: %v", model, err)
+		return nil
+	}
+
+	tc.mu.Lock()
+	tc.encoders[model] = enc
+	tc.mu.Unlock()
+	return enc
+}
+
+// Count returns the number of tokens text occupies for model.
+func (tc *TokenCounter) Count(model, text string) int {
+	if enc := tc.encoder(model); enc != nil {
+		return len(enc.EncodeOrdinary(text))
+	}
+	return estimateTokens(text)
+}
+
+// CountMessages returns the prompt size of msgs including chat framing.
+func (tc *TokenCounter) CountMessages(model string, msgs []Message) int {
+	total := tokensReplyPrime
+	for _, m := range msgs {
+		total += tc.countMessage(model, m)
+	}
+	return total
+}
+
+func (tc *TokenCounter) countMessage(model string, m Message) int {
+	n := tokensPerMessage + tc.Count(model, m.Role) + tc.Count(model, m.Content)
+	for _, call := range m.ToolCalls {
+		n += tc.Count(model, call.Function.Name) + tc.Count(model, call.Function.Arguments)
+	}
+	return n
+}
+
+// estimateTokens over-counts on purpose: Cyrillic text averages well under
+// two characters per token on the GPT tokenizers.
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 1) / 2
+}
@@ -8,8 +8,10 @@ import (
 	"syscall"
 	"time"
 
+	"backend/auth"
 	"backend/config"
 	"backend/database"
+	"backend/llm"
 	"backend/repositories"
 	"backend/routes"
 	"backend/services"
@@ -26,6 +28,14 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// CLI subcommands (migrate, seed)
+	if handled, err := runCommand(cfg, os.Args[1:]); handled {
+		if err != nil {
+			log.Fatalf("Command failed: %v", err)
+		}
+		return
+	}
+
 	// Initialize database connection
 	db, err := database.NewConnection(cfg)
 	if err != nil {
@@ -33,11 +43,32 @@ func main() {
 	}
 	defer db.Close()
 
+	if cfg.Database.AutoMigrate {
+		if err := db.MigrateUp(context.Background()); err != nil {
+			log.Fatalf("Failed to apply migrations: %v", err)
+		}
+	}
+
+	if cfg.Database.Seed && !cfg.IsProduction() {
+		if err := db.Seed(context.Background()); err != nil {
+			log.Fatalf("Failed to seed database: %v", err)
+		}
+	}
+
 	// Initialize repositories
 	repo := repositories.NewRepository(db)
 
+	// Initialize LLM provider
+	provider, err := llm.NewProvider(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize LLM provider: %v", err)
+	}
+
+	// Initialize auth
+	tokens := auth.NewManager(cfg.Auth.JWTSecret, cfg.Auth.TokenTTL)
+
 	// Initialize services
-	service := services.NewService(repo)
+	service := services.NewService(cfg, repo, provider, tokens)
 
 	// Initialize Echo server
 	e := echo.New()
@@ -49,7 +80,12 @@ func main() {
 	e.Use(middleware.CORS())
 
 	// Setup routes
-	routes.SetupRoutes(e, service)
+	routes.SetupRoutes(e, service, tokens, cfg.Server.AllowedOrigins)
+
+	// Background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go services.RunGuestCleanup(jobsCtx, service, cfg.Auth.GuestCleanupInterval)
 
 	// Start server in a goroutine
 	go func() {
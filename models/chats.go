@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultChatPageSize = 20
+	MaxChatPageSize     = 100
+)
+
+type ChatListParams struct {
+	Limit    int
+	Cursor   string
+	Archived bool
+}
+
+// ChatCursor points at the last chat of a page; the next page starts strictly
+// after it in (last_message_at DESC, id DESC) order.
+type ChatCursor struct {
+	LastMessageAt time.Time
+	ID            uuid.UUID
+}
+
+type ChatPage struct {
+	Chats      []Chat `json:"chats"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type UpdateChatRequest struct {
+	Title    *string `json:"title" validate:"omitempty,max=200"`
+	Archived *bool   `json:"archived"`
+}
@@ -0,0 +1,43 @@
+package models
+
+// EligibilityRequest describes the client. Only segment is required; a
+// criterion left out is not checked.
+type EligibilityRequest struct {
+	Segment       string `json:"segment" validate:"required,oneof=retail business"`
+	Age           *int   `json:"age" validate:"omitempty,gte=0,lte=120"`
+	Amount        *int64 `json:"amount" validate:"omitempty,gte=0"`
+	Term          *int   `json:"term" validate:"omitempty,gte=0"`
+	TermUnit      string `json:"term_unit" validate:"omitempty,oneof=day month"` // default month
+	HasCollateral *bool  `json:"has_collateral"`
+	Purpose       string `json:"purpose" validate:"omitempty,oneof=purchase housing cushion savings liquidity working_capital equipment investment placement payments"`
+}
+
+// Rules reported in RuleCheck.Rule.
+const (
+	RuleAge        = "age"
+	RuleAmount     = "amount"
+	RuleTerm       = "term"
+	RuleCollateral = "collateral"
+	RulePurpose    = "purpose"
+)
+
+type RuleCheck struct {
+	Rule   string `json:"rule"`
+	Passed bool   `json:"passed"`
+	Reason string `json:"reason"`
+}
+
+type ProductMatch struct {
+	Product Product     `json:"product"`
+	Checks  []RuleCheck `json:"checks"`
+}
+
+// EligibilityResult splits the catalog of the requested segment into
+// eligible and rejected products. NearestAlternative is set only when nothing
+// is eligible and is the rejected product that misses the fewest rules by the
+// smallest margin.
+type EligibilityResult struct {
+	Eligible           []ProductMatch `json:"eligible"`
+	Rejected           []ProductMatch `json:"rejected"`
+	NearestAlternative *ProductMatch  `json:"nearest_alternative,omitempty"`
+}
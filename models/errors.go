@@ -0,0 +1,10 @@
+package models
+
+import "errors"
+
+// ErrNotFound is returned by repositories and services when the requested
+// record does not exist (or is not visible to the caller).
+var ErrNotFound = errors.New("not found")
+
+// ErrAlreadyExists is returned when an insert hits a unique constraint.
+var ErrAlreadyExists = errors.New("already exists")
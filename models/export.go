@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Export formats of a chat.
+const (
+	ExportMarkdown = "md"
+	ExportJSON     = "json"
+	ExportHTML     = "html"
+)
+
+// ChatExportVersion is bumped whenever ChatExport changes incompatibly.
+const ChatExportVersion = 1
+
+// ChatExport is the JSON export of a chat: the chat as get-chat returns it,
+// tool steps included, so it can be imported again.
+type ChatExport struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Chat       *Chat     `json:"chat"`
+}
+
+// ExportFile is a rendered export, ready to be downloaded.
+type ExportFile struct {
+	Name        string
+	ContentType string
+	Body        []byte
+}
@@ -0,0 +1,70 @@
+package models
+
+// CushionRequest describes the client's spending for a safety cushion plan.
+type CushionRequest struct {
+	MonthlyExpenses     int64 `json:"monthly_expenses" validate:"required,gt=0"`
+	Months              int   `json:"months" validate:"omitempty,gte=1,lte=24"` // default CushionMaxMonths
+	CurrentSavings      int64 `json:"current_savings" validate:"gte=0"`
+	MonthlyContribution int64 `json:"monthly_contribution" validate:"gte=0"`
+}
+
+// The cushion the coach recommends covers 3–6 months of expenses.
+const (
+	CushionMinMonths = 3
+	CushionMaxMonths = 6
+)
+
+// CushionPlan sizes a safety cushion and splits it between the flexible
+// "Копилка" and the higher-yield "Вакала". Amounts are in tenge.
+type CushionPlan struct {
+	MinTarget     int64 `json:"min_target"`
+	MaxTarget     int64 `json:"max_target"`
+	Months        int   `json:"months"`
+	Target        int64 `json:"target"`
+	KopilkaAmount int64 `json:"kopilka_amount"`
+	VakalaAmount  int64 `json:"vakala_amount"`
+	Remaining     int64 `json:"remaining"`
+	// MonthsToGoal is set when a monthly contribution was given.
+	MonthsToGoal *int `json:"months_to_goal,omitempty"`
+}
+
+// Schedule kinds.
+const (
+	ScheduleBNPL     = "bnpl"     // equal installments, no markup
+	ScheduleMurabaha = "murabaha" // fixed contractual markup spread evenly over the term
+	ScheduleMortgage = "mortgage" // markup on the declining balance after a down payment
+)
+
+type ScheduleRequest struct {
+	Kind   string `json:"kind" validate:"required,oneof=bnpl murabaha mortgage"`
+	Amount int64  `json:"amount" validate:"required,gt=0"`        // purchase or property price, tenge
+	Term   int    `json:"term" validate:"required,gte=1,lte=360"` // months
+	// MarkupRate is the contractual markup in % per year. Ignored for bnpl.
+	MarkupRate  float64 `json:"markup_rate" validate:"gte=0,lte=100"`
+	DownPayment int64   `json:"down_payment" validate:"gte=0"` // mortgage only
+}
+
+// SchedulePeriod is one monthly payment. Remaining is the balance owed after
+// it; for murabaha that includes the unpaid part of the fixed markup.
+type SchedulePeriod struct {
+	Period    int   `json:"period"`
+	Payment   int64 `json:"payment"`
+	Principal int64 `json:"principal"`
+	Markup    int64 `json:"markup"`
+	Remaining int64 `json:"remaining"`
+}
+
+// Schedule is a full payment plan. Amounts are whole tenge; rounding
+// differences are settled in the last period.
+type Schedule struct {
+	Kind           string           `json:"kind"`
+	Price          int64            `json:"price"`
+	DownPayment    int64            `json:"down_payment"`
+	Financed       int64            `json:"financed"`
+	Term           int              `json:"term"`
+	MarkupRate     float64          `json:"markup_rate"`
+	MonthlyPayment int64            `json:"monthly_payment"`
+	TotalMarkup    int64            `json:"total_markup"`
+	TotalPayment   int64            `json:"total_payment"`
+	Periods        []SchedulePeriod `json:"periods"`
+}
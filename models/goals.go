@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	GoalTypeCushion           = "cushion"
+	GoalTypePurchase          = "purchase"
+	GoalTypeHousing           = "housing"
+	GoalTypeBusinessLiquidity = "business_liquidity"
+
+	GoalStatusActive   = "active"
+	GoalStatusAchieved = "achieved"
+	GoalStatusArchived = "archived"
+
+	ActionStatusPending = "pending"
+	ActionStatusDone    = "done"
+)
+
+// Goal is a user's savings goal with its roadmap of micro-actions. Dates are
+// YYYY-MM-DD. Progress, ActionsDone and ActionsTotal are computed by the
+// service.
+type Goal struct {
+	ID           uuid.UUID    `json:"id"`
+	UserID       uuid.UUID    `json:"-"`
+	Type         string       `json:"type"`
+	Title        string       `json:"title"`
+	TargetAmount int64        `json:"target_amount"`
+	SavedAmount  int64        `json:"saved_amount"`
+	Deadline     *string      `json:"deadline,omitempty"`
+	ProductID    *uuid.UUID   `json:"product_id,omitempty"`
+	ProductName  string       `json:"product_name,omitempty"`
+	Status       string       `json:"status"`
+	Progress     int          `json:"progress"` // percent of target saved, 0–100
+	ActionsDone  int          `json:"actions_done"`
+	ActionsTotal int          `json:"actions_total"`
+	Actions      []GoalAction `json:"actions"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// GoalAction is a micro-action. It is Blocked while the action it depends on
+// is not done.
+type GoalAction struct {
+	ID          uuid.UUID  `json:"id"`
+	GoalID      uuid.UUID  `json:"goal_id"`
+	Description string     `json:"description"`
+	Amount      int64      `json:"amount"` // added to the goal's saved amount on completion
+	DueDate     *string    `json:"due_date,omitempty"`
+	DependsOn   *uuid.UUID `json:"depends_on,omitempty"`
+	Status      string     `json:"status"`
+	Blocked     bool       `json:"blocked"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type CreateGoalRequest struct {
+	Type         string     `json:"type" validate:"required,oneof=cushion purchase housing business_liquidity"`
+	Title        string     `json:"title" validate:"required,max=200"`
+	TargetAmount int64      `json:"target_amount" validate:"required,gt=0"`
+	SavedAmount  int64      `json:"saved_amount" validate:"gte=0"`
+	Deadline     *string    `json:"deadline" validate:"omitempty,datetime=2006-01-02"`
+	ProductID    *uuid.UUID `json:"product_id"`
+}
+
+// UpdateGoalRequest changes the given fields only. A missing and a null
+// field both mean "keep"; the Clear flags remove the optional ones.
+type UpdateGoalRequest struct {
+	Title         *string    `json:"title" validate:"omitempty,min=1,max=200"`
+	TargetAmount  *int64     `json:"target_amount" validate:"omitempty,gt=0"`
+	SavedAmount   *int64     `json:"saved_amount" validate:"omitempty,gte=0"`
+	Deadline      *string    `json:"deadline" validate:"omitempty,datetime=2006-01-02"`
+	ClearDeadline bool       `json:"clear_deadline" validate:"excluded_with=Deadline"`
+	ProductID     *uuid.UUID `json:"product_id"`
+	ClearProduct  bool       `json:"clear_product" validate:"excluded_with=ProductID"`
+	Status        *string    `json:"status" validate:"omitempty,oneof=active achieved archived"`
+}
+
+type CreateGoalActionRequest struct {
+	Description string     `json:"description" validate:"required,max=500"`
+	Amount      int64      `json:"amount" validate:"gte=0"`
+	DueDate     *string    `json:"due_date" validate:"omitempty,datetime=2006-01-02"`
+	DependsOn   *uuid.UUID `json:"depends_on"`
+}
+
+// UpdateGoalActionRequest changes the given fields only, like
+// UpdateGoalRequest. Completion goes through its own endpoint so the goal's
+// saved amount stays consistent.
+type UpdateGoalActionRequest struct {
+	Description    *string    `json:"description" validate:"omitempty,min=1,max=500"`
+	Amount         *int64     `json:"amount" validate:"omitempty,gte=0"`
+	DueDate        *string    `json:"due_date" validate:"omitempty,datetime=2006-01-02"`
+	ClearDueDate   bool       `json:"clear_due_date" validate:"excluded_with=DueDate"`
+	DependsOn      *uuid.UUID `json:"depends_on"`
+	ClearDependsOn bool       `json:"clear_depends_on" validate:"excluded_with=DependsOn"`
+}
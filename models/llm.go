@@ -7,31 +7,115 @@ import (
 )
 
 type Chat struct {
-	ID        uuid.UUID `json:"id"`
-	Title     string    `json:"title,omitempty"`
-	Model     string    `json:"model"` // lives on chat
-	CreatedAt time.Time `json:"created_at"`
-	Messages  []Message `json:"messages"`
+	ID            uuid.UUID  `json:"id"`
+	UserID        uuid.UUID  `json:"-"`
+	Title         string     `json:"title,omitempty"`
+	Model         string     `json:"model"` // lives on chat
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	LastMessageAt time.Time  `json:"last_message_at"`
+	ArchivedAt    *time.Time `json:"archived_at,omitempty"`
+	// ActiveLeafID ends the branch the chat shows and continues.
+	ActiveLeafID *uuid.UUID `json:"active_leaf_id,omitempty"`
+	Messages     []Message  `json:"messages,omitempty"`
+
+	Summary *ChatSummary `json:"summary,omitempty"`
 }
 
 type Message struct {
-	ID        uuid.UUID `json:"id,omitempty"`
-	ChatID    uuid.UUID `json:"chat_id,omitempty"`
-	Role      string    `json:"role,omitempty"` // "user" | "assistant"
-	Content   string    `json:"content,omitempty"`
+	ID     uuid.UUID `json:"id,omitempty"`
+	ChatID uuid.UUID `json:"chat_id,omitempty"`
+	// ParentID is the message this one follows; siblings are alternative
+	// replies or edited questions.
+	ParentID   *uuid.UUID `json:"parent_id,omitempty"`
+	Role       string     `json:"role,omitempty"` // "user" | "assistant" | "tool"
+	Content    string     `json:"content,omitempty"`
+	Status     string     `json:"status,omitempty"` // MessageStatus*
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"` // set on "tool" messages
+	// Structured is the parsed reply when it was requested in structured
+	// mode; Content then holds the raw JSON the model returned.
+	Structured *StructuredReply `json:"structured,omitempty"`
+	// Suggestions are the quick replies offered at the end of an assistant
+	// message; SuggestionID marks a user message sent by tapping one.
+	Suggestions  []Suggestion `json:"suggestions,omitempty"`
+	SuggestionID *uuid.UUID   `json:"suggestion_id,omitempty"`
+	// Model answered this assistant message; after a provider fallback it
+	// differs from the chat's model.
+	Model string `json:"model,omitempty"`
+	// Error is the code of what went wrong with a failed or cancelled reply,
+	// e.g. "llm_overloaded".
+	Error     string    `json:"error,omitempty"`
 	CreatedAt time.Time `json:"created_at,omitempty"`
 }
 
+// ToolCall is a backend function the assistant invoked while answering.
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"` // JSON as produced by the model
+}
+
+// IsToolStep reports whether m is an intermediate tool call or tool result
+// rather than part of the visible conversation.
+func (m *Message) IsToolStep() bool {
+	return m.Role == "tool" || len(m.ToolCalls) > 0
+}
+
+// Unfinished reports whether m is a reply still being generated or one that
+// failed. It stays in the chat for the user but is not replayed to the model.
+func (m *Message) Unfinished() bool {
+	return m.Status == MessageStatusPending || m.Status == MessageStatusFailed
+}
+
+// LLMChatRequest carries either typed Content or the SuggestionID of a quick
+// reply the user tapped.
 type LLMChatRequest struct {
-	Content string `json:"content"`
+	Content      string     `json:"content"`
+	SuggestionID *uuid.UUID `json:"suggestion_id"`
+	Structured   bool       `json:"structured"` // see ReplyOptions
+	Model        string     `json:"model"`      // only on /start, from GET /models; default if empty
+}
+
+// RetryRequest re-generates a reply, failed for retry or any for regenerate.
+type RetryRequest struct {
+	Structured bool `json:"structured"` // see ReplyOptions
+}
+
+// EditMessageRequest sends a corrected version of an earlier user message.
+type EditMessageRequest struct {
+	Content    string `json:"content" validate:"required"`
+	Structured bool   `json:"structured"` // see ReplyOptions
+}
+
+// Turn is a user message together with the reply to it.
+type Turn struct {
+	Request *Message `json:"request"`
+	Reply   *Message `json:"reply"`
+}
+
+type Suggestion struct {
+	ID   uuid.UUID `json:"id"`
+	Text string    `json:"text"`
+}
+
+// ReplyOptions tune how the assistant answers a single message.
+type ReplyOptions struct {
+	// Structured asks for a StructuredReply instead of free text.
+	Structured bool
 }
 
-type LLMAPIRequest struct {
-	Model    string        `json:"model"`
-	Messages []MessagesAPI `json:"messages"`
+// StructuredReply is the four-part answer the coach prompt mandates, in a
+// form clients can render: Choices become buttons.
+type StructuredReply struct {
+	Reflection      string           `json:"reflection" validate:"required"`
+	Steps           []string         `json:"steps" validate:"min=1,max=3,dive,required"`
+	Recommendations []Recommendation `json:"recommendations" validate:"max=2,dive"`
+	Choices         []string         `json:"choices" validate:"min=2,max=3,dive,required,max=80"`
+	Question        string           `json:"question" validate:"required"`
 }
 
-type MessagesAPI struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+type Recommendation struct {
+	Product string `json:"product" validate:"required"`
+	Why     string `json:"why" validate:"required"`
 }
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SegmentRetail   = "retail"
+	SegmentBusiness = "business"
+
+	TermUnitDay   = "day"
+	TermUnitMonth = "month"
+)
+
+// Purposes a product is meant for; see Product.Purposes.
+const (
+	PurposePurchase       = "purchase"
+	PurposeHousing        = "housing"
+	PurposeCushion        = "cushion"
+	PurposeSavings        = "savings"
+	PurposeLiquidity      = "liquidity"
+	PurposeWorkingCapital = "working_capital"
+	PurposeEquipment      = "equipment"
+	PurposeInvestment     = "investment"
+	PurposePlacement      = "placement"
+	PurposePayments       = "payments"
+)
+
+// Product is an entry of the Zaman product catalog. Nil bounds mean the
+// product has no limit on that side. Amounts are in tenge.
+type Product struct {
+	ID            uuid.UUID `json:"id"`
+	Code          string    `json:"code"`
+	Name          string    `json:"name"`
+	Segment       string    `json:"segment"`
+	Description   string    `json:"description,omitempty"`
+	MinAmount     *int64    `json:"min_amount,omitempty"`
+	MaxAmount     *int64    `json:"max_amount,omitempty"`
+	MinTerm       *int      `json:"min_term,omitempty"`
+	MaxTerm       *int      `json:"max_term,omitempty"`
+	TermUnit      string    `json:"term_unit"`
+	MinAge        *int      `json:"min_age,omitempty"`
+	MaxAge        *int      `json:"max_age,omitempty"`
+	ExpectedYield *float64  `json:"expected_yield,omitempty"` // % per year, not guaranteed
+	Fees          string    `json:"fees,omitempty"`
+	// Purposes lists what the product is for; empty means any purpose.
+	Purposes           []string  `json:"purposes"`
+	RequiresCollateral bool      `json:"requires_collateral"`
+	Active             bool      `json:"active"`
+	SortOrder          int       `json:"sort_order"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+type ProductRequest struct {
+	Code          string   `json:"code" validate:"required,max=64"`
+	Name          string   `json:"name" validate:"required,max=200"`
+	Segment       string   `json:"segment" validate:"required,oneof=retail business"`
+	Description   string   `json:"description" validate:"max=2000"`
+	MinAmount     *int64   `json:"min_amount" validate:"omitempty,gte=0"`
+	MaxAmount     *int64   `json:"max_amount" validate:"omitempty,gte=0"`
+	MinTerm       *int     `json:"min_term" validate:"omitempty,gte=0"`
+	MaxTerm       *int     `json:"max_term" validate:"omitempty,gte=0"`
+	TermUnit      string   `json:"term_unit" validate:"omitempty,oneof=day month"`
+	MinAge        *int     `json:"min_age" validate:"omitempty,gte=0,lte=120"`
+	MaxAge        *int     `json:"max_age" validate:"omitempty,gte=0,lte=120"`
+	ExpectedYield *float64 `json:"expected_yield" validate:"omitempty,gte=0,lt=1000"`
+	Fees          string   `json:"fees" validate:"max=2000"`
+	Purposes      []string `json:"purposes" validate:"dive,oneof=purchase housing cushion savings liquidity working_capital equipment investment placement payments"`
+	// RequiresCollateral is only checked when the client states whether they have collateral.
+	RequiresCollateral bool  `json:"requires_collateral"`
+	Active             *bool `json:"active"`
+	SortOrder          int   `json:"sort_order"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultSearchPageSize = 20
+	MaxSearchPageSize     = 50
+)
+
+type SearchParams struct {
+	Query  string
+	Limit  int
+	Cursor string
+}
+
+// SearchCursor points at the last hit of a page; the next page starts
+// strictly after it in (rank DESC, id DESC) order.
+type SearchCursor struct {
+	Rank float32
+	ID   uuid.UUID
+}
+
+// SearchHit is a message, or a chat title if MessageID is nil, matching a
+// search. Snippet is plain text with the matched words wrapped in **.
+type SearchHit struct {
+	ChatID    uuid.UUID  `json:"chat_id"`
+	ChatTitle string     `json:"chat_title,omitempty"`
+	MessageID *uuid.UUID `json:"message_id,omitempty"`
+	Role      string     `json:"role,omitempty"`
+	// ActiveBranch is false for a message on a branch the chat does not
+	// show; activating MessageID switches to it.
+	ActiveBranch bool      `json:"active_branch"`
+	Snippet      string    `json:"snippet"`
+	Rank         float32   `json:"rank"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type SearchPage struct {
+	Hits       []SearchHit `json:"hits"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
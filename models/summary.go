@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChatSummary is a compact memory of older turns that replaces them in the
+// prompt.
+type ChatSummary struct {
+	ID         uuid.UUID   `json:"id"`
+	ChatID     uuid.UUID   `json:"chat_id"`
+	Content    string      `json:"content"`
+	MessageIDs []uuid.UUID `json:"message_ids"`
+	Model      string      `json:"model"`
+	CreatedAt  time.Time   `json:"created_at"`
+
+	// Stale is set when a covered message no longer exists; such a summary
+	// is ignored and regenerated.
+	Stale bool `json:"stale,omitempty"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type User struct {
+	ID           uuid.UUID  `json:"id"`
+	Email        string     `json:"email,omitempty"`
+	Name         string     `json:"name,omitempty"`
+	PasswordHash string     `json:"-"`
+	IsGuest      bool       `json:"is_guest,omitempty"`
+	IsAdmin      bool       `json:"is_admin,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"` // guests only
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type RegisterRequest struct {
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"required,min=8,max=72"` // bcrypt ignores bytes past 72
+	Name     string `json:"name" validate:"max=100"`
+
+	// GuestToken, when set, moves the guest's chats to the new account.
+	GuestToken string `json:"guest_token"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+
+	GuestToken string `json:"guest_token"`
+}
+
+type ClaimGuestRequest struct {
+	GuestToken string `json:"guest_token" validate:"required"`
+}
+
+type AuthResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      *User     `json:"user"`
+
+	ClaimedChats int64 `json:"claimed_chats,omitempty"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "github.com/google/uuid"
+
+// WebSocket frame types. Clients send WSTypeMessage; everything else flows
+// from the server.
+const (
+	WSTypeMessage  = "message"  // a persisted chat message (user or assistant)
+	WSTypeDelta    = "delta"    // streamed fragment of the assistant reply
+	WSTypeThinking = "thinking" // assistant started or stopped working on a reply
+	WSTypeTitle    = "title"    // chat title changed
+	WSTypeError    = "error"
+)
+
+type WSEnvelope struct {
+	Type   string    `json:"type"`
+	ChatID uuid.UUID `json:"chat_id"`
+	Data   any       `json:"data,omitempty"`
+}
+
+// WSIncoming is what clients send over the socket.
+type WSIncoming struct {
+	Type         string     `json:"type"`
+	Content      string     `json:"content"`
+	SuggestionID *uuid.UUID `json:"suggestion_id"` // instead of content, see LLMChatRequest
+}
+
+type WSDelta struct {
+	Content string `json:"content"`
+}
+
+type WSThinking struct {
+	Active bool `json:"active"`
+}
+
+type WSTitle struct {
+	Title string `json:"title"`
+}
+
+type WSError struct {
+	Error string `json:"error"`
+	Code  string `json:"code,omitempty"` // same codes as the HTTP API
+}
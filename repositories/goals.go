@@ -0,0 +1,262 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"backend/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const goalColumns = `g.id, g.user_id, g.type, g.title, g.target_amount, g.saved_amount, g.deadline::text,
+	g.product_id, COALESCE(p.name, ''), g.status, g.created_at, g.updated_at`
+
+const goalFrom = `goals g LEFT JOIN products p ON p.id = g.product_id`
+
+func scanGoal(row pgx.Row) (*models.Goal, error) {
+	g := &models.Goal{}
+	err := row.Scan(&g.ID, &g.UserID, &g.Type, &g.Title, &g.TargetAmount, &g.SavedAmount, &g.Deadline,
+		&g.ProductID, &g.ProductName, &g.Status, &g.CreatedAt, &g.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// ListGoals returns the user's goals with their actions, newest first. An
+// empty status lists every goal.
+func (r *repository) ListGoals(ctx context.Context, userID uuid.UUID, status string) ([]models.Goal, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT `+goalColumns+`
+		FROM `+goalFrom+`
+		WHERE g.user_id = $1 AND ($2 = '' OR g.status = $2)
+		ORDER BY g.created_at DESC, g.id DESC
+	`, userID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	goals := make([]models.Goal, 0, 8)
+	for rows.Next() {
+		g, err := scanGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, *g)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	if err := r.attachGoalActions(ctx, goals); err != nil {
+		return nil, err
+	}
+	return goals, nil
+}
+
+func (r *repository) GetGoal(ctx context.Context, userID, id uuid.UUID) (*models.Goal, error) {
+	g, err := scanGoal(r.q.QueryRow(ctx, `
+		SELECT `+goalColumns+`
+		FROM `+goalFrom+`
+		WHERE g.id = $1 AND g.user_id = $2
+	`, id, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	goals := []models.Goal{*g}
+	if err := r.attachGoalActions(ctx, goals); err != nil {
+		return nil, err
+	}
+	return &goals[0], nil
+}
+
+// LockGoal takes a row lock on the goal until the surrounding transaction
+// ends, serializing changes to its actions. Outside WithTx it is useless.
+func (r *repository) LockGoal(ctx context.Context, userID, id uuid.UUID) error {
+	var found bool
+	err := r.q.QueryRow(ctx, `
+		SELECT true FROM goals WHERE id = $1 AND user_id = $2 FOR UPDATE
+	`, id, userID).Scan(&found)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.ErrNotFound
+	}
+	return err
+}
+
+func (r *repository) attachGoalActions(ctx context.Context, goals []models.Goal) error {
+	if len(goals) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(goals))
+	index := make(map[uuid.UUID]int, len(goals))
+	for i := range goals {
+		goals[i].Actions = []models.GoalAction{}
+		ids = append(ids, goals[i].ID.String())
+		index[goals[i].ID] = i
+	}
+
+	rows, err := r.q.Query(ctx, `
+		SELECT id, goal_id, description, amount, due_date::text, depends_on, status, completed_at, created_at, updated_at
+		FROM goal_actions
+		WHERE goal_id = ANY($1::uuid[])
+		ORDER BY created_at, id
+	`, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.GoalAction
+		if err := rows.Scan(&a.ID, &a.GoalID, &a.Description, &a.Amount, &a.DueDate, &a.DependsOn,
+			&a.Status, &a.CompletedAt, &a.CreatedAt, &a.UpdatedAt); err != nil {
+			return err
+		}
+		g := &goals[index[a.GoalID]]
+		g.Actions = append(g.Actions, a)
+	}
+	return rows.Err()
+}
+
+func (r *repository) CreateGoal(ctx context.Context, g *models.Goal) error {
+	return r.q.QueryRow(ctx, `
+		INSERT INTO goals (user_id, type, title, target_amount, saved_amount, deadline, product_id, status)
+		VALUES ($1, $2, $3, $4, $5, $6::date, $7,
+			CASE WHEN $5 >= $4 THEN 'achieved' ELSE 'active' END)
+		RETURNING id, status, created_at, updated_at
+	`, g.UserID, g.Type, g.Title, g.TargetAmount, g.SavedAmount, g.Deadline, g.ProductID).
+		Scan(&g.ID, &g.Status, &g.CreatedAt, &g.UpdatedAt)
+}
+
+// UpdateGoal applies the non-nil fields of req and its Clear flags. An
+// active goal whose saved amount reaches the target becomes achieved unless
+// req sets a status.
+func (r *repository) UpdateGoal(ctx context.Context, userID, id uuid.UUID, req *models.UpdateGoalRequest) error {
+	tag, err := r.q.Exec(ctx, `
+		UPDATE goals SET
+			title = COALESCE($3, title),
+			target_amount = COALESCE($4, target_amount),
+			saved_amount = COALESCE($5, saved_amount),
+			deadline = CASE WHEN $9 THEN NULL ELSE COALESCE($6::date, deadline) END,
+			product_id = CASE WHEN $10 THEN NULL ELSE COALESCE($7, product_id) END,
+			status = COALESCE($8, CASE
+				WHEN status = 'active' AND COALESCE($5, saved_amount) >= COALESCE($4, target_amount) THEN 'achieved'
+				ELSE status
+			END),
+			updated_at = now()
+		WHERE id = $1 AND user_id = $2
+	`, id, userID, req.Title, req.TargetAmount, req.SavedAmount, req.Deadline, req.ProductID, req.Status,
+		req.ClearDeadline, req.ClearProduct)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+func (r *repository) DeleteGoal(ctx context.Context, userID, id uuid.UUID) error {
+	tag, err := r.q.Exec(ctx, `DELETE FROM goals WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// Goal actions are addressed through their goal; callers must have checked
+// ownership of the goal already.
+
+func (r *repository) CreateGoalAction(ctx context.Context, a *models.GoalAction) error {
+	return r.q.QueryRow(ctx, `
+		INSERT INTO goal_actions (goal_id, description, amount, due_date, depends_on)
+		VALUES ($1, $2, $3, $4::date, $5)
+		RETURNING id, status, created_at, updated_at
+	`, a.GoalID, a.Description, a.Amount, a.DueDate, a.DependsOn).
+		Scan(&a.ID, &a.Status, &a.CreatedAt, &a.UpdatedAt)
+}
+
+// UpdateGoalAction applies the non-nil fields of req and its Clear flags.
+func (r *repository) UpdateGoalAction(ctx context.Context, goalID, id uuid.UUID, req *models.UpdateGoalActionRequest) error {
+	tag, err := r.q.Exec(ctx, `
+		UPDATE goal_actions SET
+			description = COALESCE($3, description),
+			amount = COALESCE($4, amount),
+			due_date = CASE WHEN $7 THEN NULL ELSE COALESCE($5::date, due_date) END,
+			depends_on = CASE WHEN $8 THEN NULL ELSE COALESCE($6, depends_on) END,
+			updated_at = now()
+		WHERE id = $1 AND goal_id = $2
+	`, id, goalID, req.Description, req.Amount, req.DueDate, req.DependsOn,
+		req.ClearDueDate, req.ClearDependsOn)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// CompleteGoalAction marks a pending action done and credits its amount to
+// the goal in one transaction. Completing a done action is a no-op.
+func (r *repository) CompleteGoalAction(ctx context.Context, goalID, id uuid.UUID) error {
+	return pgx.BeginFunc(ctx, r.q, func(tx pgx.Tx) error {
+		var amount int64
+		err := tx.QueryRow(ctx, `
+			UPDATE goal_actions SET status = 'done', completed_at = now(), updated_at = now()
+			WHERE id = $1 AND goal_id = $2 AND status = 'pending'
+			RETURNING amount
+		`, id, goalID).Scan(&amount)
+		if errors.Is(err, pgx.ErrNoRows) {
+			var exists bool
+			if err := tx.QueryRow(ctx, `
+				SELECT EXISTS (SELECT 1 FROM goal_actions WHERE id = $1 AND goal_id = $2)
+			`, id, goalID).Scan(&exists); err != nil {
+				return err
+			}
+			if !exists {
+				return models.ErrNotFound
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if amount == 0 {
+			return nil
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE goals SET
+				saved_amount = saved_amount + $2,
+				status = CASE
+					WHEN status = 'active' AND saved_amount + $2 >= target_amount THEN 'achieved'
+					ELSE status
+				END,
+				updated_at = now()
+			WHERE id = $1
+		`, goalID, amount)
+		return err
+	})
+}
+
+func (r *repository) DeleteGoalAction(ctx context.Context, goalID, id uuid.UUID) error {
+	tag, err := r.q.Exec(ctx, `DELETE FROM goal_actions WHERE id = $1 AND goal_id = $2`, id, goalID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
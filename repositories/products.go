@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"backend/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const productColumns = `id, code, name, segment, description, min_amount, max_amount, min_term, max_term,
+	term_unit, min_age, max_age, expected_yield::float8, fees, purposes, requires_collateral, active, sort_order,
+	created_at, updated_at`
+
+func scanProduct(row pgx.Row) (*models.Product, error) {
+	p := &models.Product{}
+	err := row.Scan(&p.ID, &p.Code, &p.Name, &p.Segment, &p.Description, &p.MinAmount, &p.MaxAmount,
+		&p.MinTerm, &p.MaxTerm, &p.TermUnit, &p.MinAge, &p.MaxAge, &p.ExpectedYield, &p.Fees,
+		&p.Purposes, &p.RequiresCollateral, &p.Active, &p.SortOrder, &p.CreatedAt, &p.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *repository) ListProducts(ctx context.Context, activeOnly bool) ([]models.Product, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT `+productColumns+`
+		FROM products
+		WHERE active OR NOT $1
+		ORDER BY sort_order, name
+	`, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]models.Product, 0, 16)
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, *p)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return products, nil
+}
+
+func (r *repository) GetProduct(ctx context.Context, id uuid.UUID) (*models.Product, error) {
+	return scanProduct(r.q.QueryRow(ctx, `
+		SELECT `+productColumns+`
+		FROM products
+		WHERE id = $1
+	`, id))
+}
+
+func (r *repository) CreateProduct(ctx context.Context, p *models.Product) (*models.Product, error) {
+	created, err := scanProduct(r.q.QueryRow(ctx, `
+		INSERT INTO products (code, name, segment, description, min_amount, max_amount, min_term, max_term,
+			term_unit, min_age, max_age, expected_yield, fees, purposes, requires_collateral, active, sort_order)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14::text[], $15, $16, $17)
+		RETURNING `+productColumns,
+		p.Code, p.Name, p.Segment, p.Description, p.MinAmount, p.MaxAmount, p.MinTerm, p.MaxTerm,
+		p.TermUnit, p.MinAge, p.MaxAge, p.ExpectedYield, p.Fees, p.Purposes, p.RequiresCollateral,
+		p.Active, p.SortOrder))
+	if isUniqueViolation(err) {
+		return nil, models.ErrAlreadyExists
+	}
+	return created, err
+}
+
+func (r *repository) UpdateProduct(ctx context.Context, p *models.Product) (*models.Product, error) {
+	updated, err := scanProduct(r.q.QueryRow(ctx, `
+		UPDATE products SET
+			code = $2, name = $3, segment = $4, description = $5, min_amount = $6, max_amount = $7,
+			min_term = $8, max_term = $9, term_unit = $10, min_age = $11, max_age = $12,
+			expected_yield = $13, fees = $14, purposes = $15::text[], requires_collateral = $16,
+			active = $17, sort_order = $18, updated_at = now()
+		WHERE id = $1
+		RETURNING `+productColumns,
+		p.ID, p.Code, p.Name, p.Segment, p.Description, p.MinAmount, p.MaxAmount, p.MinTerm, p.MaxTerm,
+		p.TermUnit, p.MinAge, p.MaxAge, p.ExpectedYield, p.Fees, p.Purposes, p.RequiresCollateral,
+		p.Active, p.SortOrder))
+	if isUniqueViolation(err) {
+		return nil, models.ErrAlreadyExists
+	}
+	return updated, err
+}
+
+func (r *repository) DeleteProduct(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.q.Exec(ctx, `DELETE FROM products WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
@@ -4,28 +4,94 @@ import (
 	"backend/database"
 	"backend/models"
 	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type Repository interface {
-	GetChatAndMessages(ctx context.Context, id uuid.UUID) (*models.Chat, error)
+	// WithTx runs fn with a Repository bound to a database transaction,
+	// committed if fn returns nil and rolled back otherwise. Nested calls use
+	// savepoints.
+	WithTx(ctx context.Context, fn func(Repository) error) error
+
+	GetChatAndMessages(ctx context.Context, userID, id uuid.UUID, tree bool) (*models.Chat, error)
 	SaveMessage(ctx context.Context, message *models.Message) error
-	CreateNewChat(ctx context.Context, uuid uuid.UUID, chatName string) error
+	FinalizeMessage(ctx context.Context, message *models.Message) error
+	TransitionMessageStatus(ctx context.Context, id uuid.UUID, from, to string) error
+	ReclaimPendingMessage(ctx context.Context, id uuid.UUID, before time.Time) error
+	SetActiveLeaf(ctx context.Context, chatID, messageID uuid.UUID) error
+	DeleteMessages(ctx context.Context, ids []uuid.UUID) error
+	CreateNewChat(ctx context.Context, userID, uuid uuid.UUID, chatName, model string) error
+	UpdateChatTitle(ctx context.Context, id uuid.UUID, title string) error
+	ListMessages(ctx context.Context, chatID uuid.UUID) ([]models.Message, error)
+	ListBranch(ctx context.Context, chatID uuid.UUID) ([]models.Message, error)
+	FindSuggestion(ctx context.Context, chatID, suggestionID uuid.UUID) (string, error)
+	GetLatestSummary(ctx context.Context, chatID uuid.UUID) (*models.ChatSummary, error)
+	SaveSummary(ctx context.Context, summary *models.ChatSummary) error
+	ListChats(ctx context.Context, userID uuid.UUID, limit int, cursor *models.ChatCursor, archived bool) ([]models.Chat, error)
+	Search(ctx context.Context, userID uuid.UUID, query string, limit int, cursor *models.SearchCursor) ([]models.SearchHit, error)
+	UpdateChat(ctx context.Context, userID, id uuid.UUID, title *string, archived *bool) (*models.Chat, error)
+	DeleteChat(ctx context.Context, userID, id uuid.UUID) error
+
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	CreateGuestUser(ctx context.Context, expiresAt time.Time) (*models.User, error)
+	ClaimGuestChats(ctx context.Context, guestID, userID uuid.UUID) (int64, error)
+	DeleteExpiredGuests(ctx context.Context) (int64, error)
+	DeleteGuest(ctx context.Context, id uuid.UUID) error
+
+	ListProducts(ctx context.Context, activeOnly bool) ([]models.Product, error)
+	GetProduct(ctx context.Context, id uuid.UUID) (*models.Product, error)
+	CreateProduct(ctx context.Context, p *models.Product) (*models.Product, error)
+	UpdateProduct(ctx context.Context, p *models.Product) (*models.Product, error)
+	DeleteProduct(ctx context.Context, id uuid.UUID) error
+
+	ListGoals(ctx context.Context, userID uuid.UUID, status string) ([]models.Goal, error)
+	GetGoal(ctx context.Context, userID, id uuid.UUID) (*models.Goal, error)
+	LockGoal(ctx context.Context, userID, id uuid.UUID) error
+	CreateGoal(ctx context.Context, goal *models.Goal) error
+	UpdateGoal(ctx context.Context, userID, id uuid.UUID, req *models.UpdateGoalRequest) error
+	DeleteGoal(ctx context.Context, userID, id uuid.UUID) error
+	CreateGoalAction(ctx context.Context, action *models.GoalAction) error
+	UpdateGoalAction(ctx context.Context, goalID, id uuid.UUID, req *models.UpdateGoalActionRequest) error
+	CompleteGoalAction(ctx context.Context, goalID, id uuid.UUID) error
+	DeleteGoalAction(ctx context.Context, goalID, id uuid.UUID) error
+}
+
+// querier is what repository methods run their SQL on: the pool, or a
+// transaction inside WithTx.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
 }
 
 type repository struct {
-	db *database.DB
+	q querier
 }
 
 func NewRepository(db *database.DB) Repository {
-	return &repository{db: db}
+	return &repository{q: db.Pool}
+}
+
+func (r *repository) WithTx(ctx context.Context, fn func(Repository) error) error {
+	return pgx.BeginFunc(ctx, r.q, func(tx pgx.Tx) error {
+		return fn(&repository{q: tx})
+	})
 }
 
-func (r *repository) CreateNewChat(ctx context.Context, uuid uuid.UUID, chatName string) error {
-	query := `INSERT INTO chats (id, title, model) VALUES
-	($1, $2, $3);`
-	_, err := r.db.Pool.Exec(ctx, query, uuid, chatName, models.LLMModel)
+func (r *repository) CreateNewChat(ctx context.Context, userID, uuid uuid.UUID, chatName, model string) error {
+	query := `INSERT INTO chats (id, user_id, title, model) VALUES
+	($1, $2, $3, $4);`
+	_, err := r.q.Exec(ctx, query, uuid, userID, chatName, model)
 	if err != nil {
 		return err
 	}
@@ -33,11 +99,53 @@ func (r *repository) CreateNewChat(ctx context.Context, uuid uuid.UUID, chatName
 	return nil
 }
 
+func (r *repository) UpdateChatTitle(ctx context.Context, id uuid.UUID, title string) error {
+	_, err := r.q.Exec(ctx, `UPDATE chats SET title = $2, updated_at = now() WHERE id = $1`, id, title)
+	return err
+}
+
 func (r *repository) SaveMessage(ctx context.Context, message *models.Message) error {
+	if message.Status == "" {
+		message.Status = models.MessageStatusCompleted
+	}
+	var toolCalls, structured, suggestions string
+	if len(message.ToolCalls) > 0 {
+		var err error
+		if toolCalls, err = encodeJSONB(message.ToolCalls); err != nil {
+			return err
+		}
+	}
+	if message.Structured != nil {
+		var err error
+		if structured, err = encodeJSONB(message.Structured); err != nil {
+			return err
+		}
+	}
+	if len(message.Suggestions) > 0 {
+		var err error
+		if suggestions, err = encodeJSONB(message.Suggestions); err != nil {
+			return err
+		}
+	}
+	// The message becomes the new end of the active branch; without a parent
+	// it is a root.
 	query := `
-INSERT INTO messages(chat_id, role, content) VALUES($1,$2,$3) 
+WITH m AS (
+	INSERT INTO messages(chat_id, role, content, status, tool_calls, tool_call_id, structured, suggestions, suggestion_id, model,
+		error, parent_id, created_at)
+	VALUES($1, $2, $3, $4, NULLIF($5, '')::jsonb, NULLIF($6, ''), NULLIF($7, '')::jsonb, NULLIF($8, '')::jsonb, $9, NULLIF($10, ''),
+		NULLIF($11, ''), $12, clock_timestamp())
+	RETURNING id, chat_id, parent_id, created_at
+), c AS (
+	UPDATE chats SET last_message_at = m.created_at, active_leaf_id = m.id, updated_at = now()
+	FROM m WHERE chats.id = m.chat_id
+)
+SELECT id, parent_id, created_at FROM m
 `
-	_, err := r.db.Pool.Exec(ctx, query, message.ChatID, message.Role, message.Content)
+	err := r.q.QueryRow(ctx, query, message.ChatID, message.Role, message.Content, message.Status,
+		toolCalls, message.ToolCallID, structured, suggestions, message.SuggestionID, message.Model, message.Error,
+		message.ParentID).
+		Scan(&message.ID, &message.ParentID, &message.CreatedAt)
 	if err != nil {
 		return err
 	}
@@ -45,39 +153,299 @@ INSERT INTO messages(chat_id, role, content) VALUES($1,$2,$3)
 	return nil
 }
 
-func (r *repository) GetChatAndMessages(ctx context.Context, id uuid.UUID) (*models.Chat, error) {
-	chat := &models.Chat{}
-	err := r.db.Pool.QueryRow(ctx, `
-		SELECT id, title, model, created_at
+// JSONB columns travel as text: the simple query protocol would send a
+// []byte parameter as bytea. An empty string stands for NULL.
+func encodeJSONB(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func decodeJSONB(data string, v any) error {
+	if data == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(data), v)
+}
+
+// FinalizeMessage fills in a pending message and moves it under its
+// ParentID, if set. Its timestamp moves to now so it sorts after the tool
+// steps saved while it was pending, and it ends the active branch again.
+func (r *repository) FinalizeMessage(ctx context.Context, message *models.Message) error {
+	var structured, suggestions string
+	if message.Structured != nil {
+		var err error
+		if structured, err = encodeJSONB(message.Structured); err != nil {
+			return err
+		}
+	}
+	if len(message.Suggestions) > 0 {
+		var err error
+		if suggestions, err = encodeJSONB(message.Suggestions); err != nil {
+			return err
+		}
+	}
+	query := `
+WITH m AS (
+	UPDATE messages SET
+		content = $2, status = $3, structured = NULLIF($4, '')::jsonb, suggestions = NULLIF($5, '')::jsonb,
+		model = NULLIF($6, ''), error = NULLIF($7, ''), parent_id = COALESCE($8::uuid, parent_id),
+		created_at = clock_timestamp()
+	WHERE id = $1
+	RETURNING id, chat_id, created_at
+), c AS (
+	UPDATE chats SET last_message_at = m.created_at, active_leaf_id = m.id, updated_at = now()
+	FROM m WHERE chats.id = m.chat_id
+)
+SELECT created_at FROM m
+`
+	err := r.q.QueryRow(ctx, query, message.ID, message.Content, message.Status, structured, suggestions,
+		message.Model, message.Error, message.ParentID).Scan(&message.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.ErrNotFound
+	}
+	return err
+}
+
+// TransitionMessageStatus moves a message from one status to another, or
+// returns ErrNotFound if it is not in status from.
+func (r *repository) TransitionMessageStatus(ctx context.Context, id uuid.UUID, from, to string) error {
+	tag, err := r.q.Exec(ctx, `UPDATE messages SET status = $3, error = NULL WHERE id = $1 AND status = $2`, id, from, to)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// ReclaimPendingMessage restarts the clock of a message that has been
+// pending since before, or returns ErrNotFound if it is not such a message.
+// Of concurrent callers only one succeeds: the others see the new created_at.
+func (r *repository) ReclaimPendingMessage(ctx context.Context, id uuid.UUID, before time.Time) error {
+	tag, err := r.q.Exec(ctx, `
+		UPDATE messages SET error = NULL, created_at = clock_timestamp()
+		WHERE id = $1 AND status = 'pending' AND created_at < $2
+	`, id, before)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// SetActiveLeaf makes messageID the end of the chat's active branch, or
+// returns ErrNotFound if it is not a message of the chat.
+func (r *repository) SetActiveLeaf(ctx context.Context, chatID, messageID uuid.UUID) error {
+	tag, err := r.q.Exec(ctx, `
+		UPDATE chats SET active_leaf_id = $2, updated_at = now()
+		WHERE id = $1 AND EXISTS (SELECT 1 FROM messages WHERE id = $2 AND chat_id = $1)
+	`, chatID, messageID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+func (r *repository) DeleteMessages(ctx context.Context, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = id.String()
+	}
+	_, err := r.q.Exec(ctx, `DELETE FROM messages WHERE id = ANY($1::uuid[])`, strs)
+	return err
+}
+
+// GetChatAndMessages returns a chat with its active branch, or with every
+// message of every branch if tree is set.
+func (r *repository) GetChatAndMessages(ctx context.Context, userID, id uuid.UUID, tree bool) (*models.Chat, error) {
+	chat, err := scanChat(r.q.QueryRow(ctx, `
+		SELECT `+chatColumns+`
 		FROM chats
-		WHERE id = $1
-	`, id).Scan(&chat.ID, &chat.Title, &chat.Model, &chat.CreatedAt)
+		WHERE id = $1 AND user_id = $2
+	`, id, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	if tree {
+		chat.Messages, err = r.ListMessages(ctx, id)
+	} else {
+		chat.Messages, err = r.ListBranch(ctx, id)
+	}
 	if err != nil {
 		return nil, err
 	}
+	return chat, nil
+}
 
-	rows, err := r.db.Pool.Query(ctx, `
-		SELECT id, chat_id, role, content, created_at
+// FindSuggestion returns the text of a quick reply offered by the message
+// ending the chat's active branch.
+func (r *repository) FindSuggestion(ctx context.Context, chatID, suggestionID uuid.UUID) (string, error) {
+	var text string
+	err := r.q.QueryRow(ctx, `
+		SELECT s->>'text'
+		FROM messages m CROSS JOIN LATERAL jsonb_array_elements(m.suggestions) s
+		WHERE m.id = (SELECT active_leaf_id FROM chats WHERE id = $1)
+			AND m.chat_id = $1 AND m.suggestions IS NOT NULL AND s->>'id' = $2
+	`, chatID, suggestionID.String()).Scan(&text)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", models.ErrNotFound
+	}
+	return text, err
+}
+
+const messageColumns = `id, chat_id, parent_id, role, content, status, COALESCE(tool_calls::text, ''),
+	COALESCE(tool_call_id, ''), COALESCE(structured::text, ''), COALESCE(suggestions::text, ''), suggestion_id,
+	COALESCE(model, ''), COALESCE(error, ''), created_at`
+
+// ListMessages returns every message of a chat, all branches, in order.
+// Callers must have checked ownership of the chat already.
+func (r *repository) ListMessages(ctx context.Context, chatID uuid.UUID) ([]models.Message, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT `+messageColumns+`
 		FROM messages
 		WHERE chat_id = $1
 		ORDER BY created_at ASC, id ASC
-	`, id)
+	`, chatID)
+	if err != nil {
+		return nil, err
+	}
+	return scanMessages(rows)
+}
+
+// ListBranch returns the active branch of a chat: the path from its first
+// message to the active leaf.
+func (r *repository) ListBranch(ctx context.Context, chatID uuid.UUID) ([]models.Message, error) {
+	rows, err := r.q.Query(ctx, `
+		WITH RECURSIVE branch AS (
+			SELECT m.* FROM messages m JOIN chats c ON c.active_leaf_id = m.id
+			WHERE c.id = $1
+			UNION ALL
+			SELECT p.* FROM messages p JOIN branch b ON p.id = b.parent_id
+		)
+		SELECT `+messageColumns+`
+		FROM branch
+		ORDER BY created_at ASC, id ASC
+	`, chatID)
 	if err != nil {
 		return nil, err
 	}
+	return scanMessages(rows)
+}
+
+func scanMessages(rows pgx.Rows) ([]models.Message, error) {
 	defer rows.Close()
 
-	chat.Messages = make([]models.Message, 0, 32)
+	messages := make([]models.Message, 0, 32)
 	for rows.Next() {
-		var m models.Message
-		if err := rows.Scan(&m.ID, &m.ChatID, &m.Role, &m.Content, &m.CreatedAt); err != nil {
+		var (
+			m                                  models.Message
+			toolCalls, structured, suggestions string
+		)
+		if err := rows.Scan(&m.ID, &m.ChatID, &m.ParentID, &m.Role, &m.Content, &m.Status, &toolCalls, &m.ToolCallID,
+			&structured, &suggestions, &m.SuggestionID, &m.Model, &m.Error, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := decodeJSONB(suggestions, &m.Suggestions); err != nil {
 			return nil, err
 		}
-		chat.Messages = append(chat.Messages, m)
+		if err := decodeJSONB(toolCalls, &m.ToolCalls); err != nil {
+			return nil, err
+		}
+		if structured != "" {
+			m.Structured = &models.StructuredReply{}
+			if err := decodeJSONB(structured, m.Structured); err != nil {
+				return nil, err
+			}
+		}
+		messages = append(messages, m)
 	}
 	if rows.Err() != nil {
 		return nil, rows.Err()
 	}
+	return messages, nil
+}
+
+const chatColumns = `id, COALESCE(title, ''), model, created_at, updated_at, last_message_at, archived_at, active_leaf_id`
+
+func scanChat(row pgx.Row) (*models.Chat, error) {
+	chat := &models.Chat{}
+	err := row.Scan(&chat.ID, &chat.Title, &chat.Model, &chat.CreatedAt, &chat.UpdatedAt, &chat.LastMessageAt, &chat.ArchivedAt,
+		&chat.ActiveLeafID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
 	return chat, nil
+}
+
+func (r *repository) ListChats(ctx context.Context, userID uuid.UUID, limit int, cursor *models.ChatCursor, archived bool) ([]models.Chat, error) {
+	query := `
+		SELECT ` + chatColumns + `
+		FROM chats
+		WHERE user_id = $1 AND (archived_at IS NOT NULL) = $2`
+	args := []any{userID, archived}
+	if cursor != nil {
+		query += ` AND (last_message_at, id) < ($3, $4)`
+		args = append(args, cursor.LastMessageAt, cursor.ID)
+	}
+	query += ` ORDER BY last_message_at DESC, id DESC LIMIT ` + strconv.Itoa(limit)
 
+	rows, err := r.q.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	chats := make([]models.Chat, 0, limit)
+	for rows.Next() {
+		chat, err := scanChat(rows)
+		if err != nil {
+			return nil, err
+		}
+		chats = append(chats, *chat)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return chats, nil
+}
+
+func (r *repository) UpdateChat(ctx context.Context, userID, id uuid.UUID, title *string, archived *bool) (*models.Chat, error) {
+	return scanChat(r.q.QueryRow(ctx, `
+		UPDATE chats SET
+			title = COALESCE($2, title),
+			archived_at = CASE
+				WHEN $3::boolean IS NULL THEN archived_at
+				WHEN $3::boolean THEN COALESCE(archived_at, now())
+				ELSE NULL
+			END,
+			updated_at = now()
+		WHERE id = $1 AND user_id = $4
+		RETURNING `+chatColumns, id, title, archived, userID))
+}
+
+func (r *repository) DeleteChat(ctx context.Context, userID, id uuid.UUID) error {
+	tag, err := r.q.Exec(ctx, `DELETE FROM chats WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+	return nil
 }
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"strconv"
+
+	"backend/models"
+
+	"github.com/google/uuid"
+)
+
+// searchQuery matches a query in every configuration the vectors are built
+// with; see migration 0016.
+const searchQuery = `(websearch_to_tsquery('russian', $2) || websearch_to_tsquery('english', $2)
+	|| websearch_to_tsquery('simple', $2))`
+
+// Search ranks the user's messages and chat titles against query. Snippets
+// are only cut for the rows of the page, each with the configuration that
+// matched it so stemmed forms are highlighted too.
+func (r *repository) Search(ctx context.Context, userID uuid.UUID, query string, limit int, cursor *models.SearchCursor) ([]models.SearchHit, error) {
+	page := `
+		WITH RECURSIVE q AS (SELECT ` + searchQuery + ` AS q),
+		active AS (
+			SELECT m.id, m.parent_id
+			FROM chats c JOIN messages m ON m.id = c.active_leaf_id
+			WHERE c.user_id = $1
+			UNION ALL
+			SELECT m.id, m.parent_id
+			FROM messages m JOIN active a ON m.id = a.parent_id
+		),
+		hits AS (
+			SELECT m.id, m.chat_id, COALESCE(c.title, '') AS title, m.id AS message_id, m.role, m.content AS text,
+			       m.id IN (SELECT id FROM active) AS active_branch, ts_rank(m.search, q.q) AS rank, m.created_at
+			FROM messages m JOIN chats c ON c.id = m.chat_id, q
+			WHERE c.user_id = $1 AND m.role <> 'system' AND m.search @@ q.q
+			UNION ALL
+			SELECT c.id, c.id, COALESCE(c.title, ''), NULL, '', COALESCE(c.title, ''),
+			       true, ts_rank(c.search, q.q), c.created_at
+			FROM chats c, q
+			WHERE c.user_id = $1 AND c.search @@ q.q
+		)
+		SELECT * FROM hits`
+	args := []any{userID, query}
+	if cursor != nil {
+		page += ` WHERE (rank, id) < ($3::real, $4)`
+		args = append(args, cursor.Rank, cursor.ID)
+	}
+	page += ` ORDER BY rank DESC, id DESC LIMIT ` + strconv.Itoa(limit)
+
+	sql := `
+		SELECT h.chat_id, h.title, h.message_id, h.role, h.active_branch,
+		       ts_headline(cfg.cfg, h.text, websearch_to_tsquery(cfg.cfg, $2),
+		           'StartSel=**, StopSel=**, MaxWords=30, MinWords=10, MaxFragments=2'),
+		       h.rank, h.created_at
+		FROM (` + page + `) h
+		CROSS JOIN LATERAL (SELECT CASE
+			WHEN to_tsvector('russian', h.text) @@ websearch_to_tsquery('russian', $2) THEN 'russian'
+			WHEN to_tsvector('english', h.text) @@ websearch_to_tsquery('english', $2) THEN 'english'
+			ELSE 'simple'
+		END::regconfig AS cfg) cfg
+		ORDER BY h.rank DESC, h.id DESC`
+
+	rows, err := r.q.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := make([]models.SearchHit, 0, limit)
+	for rows.Next() {
+		var h models.SearchHit
+		if err := rows.Scan(&h.ChatID, &h.ChatTitle, &h.MessageID, &h.Role, &h.ActiveBranch, &h.Snippet, &h.Rank,
+			&h.CreatedAt); err != nil {
+			return nil, err
+		}
+		hits = append(hits, h)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return hits, nil
+}
@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"backend/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *repository) GetLatestSummary(ctx context.Context, chatID uuid.UUID) (*models.ChatSummary, error) {
+	s := &models.ChatSummary{}
+	var ids []string
+	err := r.q.QueryRow(ctx, `
+		SELECT s.id, s.chat_id, s.content, s.message_ids::text[], s.model, s.created_at,
+		       (SELECT count(*) FROM messages m WHERE m.id = ANY(s.message_ids)) <> cardinality(s.message_ids)
+		FROM chat_summaries s
+		WHERE s.chat_id = $1
+		ORDER BY s.created_at DESC
+		LIMIT 1
+	`, chatID).Scan(&s.ID, &s.ChatID, &s.Content, &ids, &s.Model, &s.CreatedAt, &s.Stale)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.MessageIDs = make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return nil, err
+		}
+		s.MessageIDs = append(s.MessageIDs, parsed)
+	}
+	return s, nil
+}
+
+func (r *repository) SaveSummary(ctx context.Context, summary *models.ChatSummary) error {
+	ids := make([]string, 0, len(summary.MessageIDs))
+	for _, id := range summary.MessageIDs {
+		ids = append(ids, id.String())
+	}
+
+	return r.q.QueryRow(ctx, `
+		INSERT INTO chat_summaries (chat_id, content, message_ids, model)
+		VALUES ($1, $2, $3::uuid[], $4)
+		RETURNING id, created_at
+	`, summary.ChatID, summary.Content, ids, summary.Model).Scan(&summary.ID, &summary.CreatedAt)
+}
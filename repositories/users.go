@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const userColumns = `id, COALESCE(email, ''), name, COALESCE(password_hash, ''), is_guest, is_admin, expires_at, created_at`
+
+func scanUser(row pgx.Row) (*models.User, error) {
+	u := &models.User{}
+	err := row.Scan(&u.ID, &u.Email, &u.Name, &u.PasswordHash, &u.IsGuest, &u.IsAdmin, &u.ExpiresAt, &u.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func (r *repository) CreateUser(ctx context.Context, user *models.User) error {
+	err := r.q.QueryRow(ctx, `
+		INSERT INTO users (email, name, password_hash)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, user.Email, user.Name, user.PasswordHash).Scan(&user.ID, &user.CreatedAt)
+	if isUniqueViolation(err) {
+		return models.ErrAlreadyExists
+	}
+	return err
+}
+
+func (r *repository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return scanUser(r.q.QueryRow(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE lower(email) = lower($1)
+	`, email))
+}
+
+func (r *repository) GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return scanUser(r.q.QueryRow(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE id = $1
+	`, id))
+}
+
+func (r *repository) CreateGuestUser(ctx context.Context, expiresAt time.Time) (*models.User, error) {
+	return scanUser(r.q.QueryRow(ctx, `
+		INSERT INTO users (is_guest, expires_at)
+		VALUES (true, $1)
+		RETURNING `+userColumns, expiresAt))
+}
+
+// ClaimGuestChats moves every chat and goal owned by guestID to userID and
+// removes the guest account. It returns the number of chats moved.
+func (r *repository) ClaimGuestChats(ctx context.Context, guestID, userID uuid.UUID) (int64, error) {
+	var moved int64
+	err := pgx.BeginFunc(ctx, r.q, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE chats SET user_id = $2, updated_at = now()
+			WHERE user_id = $1
+		`, guestID, userID)
+		if err != nil {
+			return err
+		}
+		moved = tag.RowsAffected()
+
+		if _, err := tx.Exec(ctx, `UPDATE goals SET user_id = $2 WHERE user_id = $1`, guestID, userID); err != nil {
+			return err
+		}
+
+		tag, err = tx.Exec(ctx, `DELETE FROM users WHERE id = $1 AND is_guest`, guestID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return models.ErrNotFound
+		}
+		return nil
+	})
+	return moved, err
+}
+
+// DeleteGuest removes the guest id with its chats and goals. It reports
+// ErrNotFound if id is not a guest.
+func (r *repository) DeleteGuest(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.q.Exec(ctx, `DELETE FROM users WHERE id = $1 AND is_guest`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteExpiredGuests removes guests past their expiry; their chats and
+// messages go with them through ON DELETE CASCADE.
+func (r *repository) DeleteExpiredGuests(ctx context.Context) (int64, error) {
+	tag, err := r.q.Exec(ctx, `DELETE FROM users WHERE is_guest AND expires_at < now()`)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"backend/auth"
 	"backend/handlers"
 	"backend/services"
 
@@ -8,7 +9,7 @@ import (
 	"github.com/labstack/echo/v4/middleware"
 )
 
-func SetupRoutes(e *echo.Echo, service services.Service) {
+func SetupRoutes(e *echo.Echo, service services.Service, tokens *auth.Manager, wsOrigins []string) {
 	// Middleware
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
@@ -26,10 +27,56 @@ func SetupRoutes(e *echo.Echo, service services.Service) {
 	v1 := e.Group("/api/v1")
 
 	// Initialize handlers
-	handler := handlers.NewHandler(service)
+	handler := handlers.NewHandler(service, wsOrigins)
 
-	v1.GET("/get-chat/:id", handler.GetChatByID)
-	v1.POST("/llm-prompt/:id", handler.LLMChat)
-	v1.POST("/start", handler.StartNewChat)
+	// Public
+	v1.POST("/auth/register", handler.Register)
+	v1.POST("/auth/login", handler.Login)
+	v1.POST("/start", handler.StartNewChat, auth.Optional(tokens))
+	v1.GET("/models", handler.ListModels)
+
+	// Authenticated
+	api := v1.Group("", auth.Middleware(tokens))
+
+	api.GET("/auth/me", handler.Me)
+	api.POST("/auth/claim", handler.ClaimGuest, auth.RequireRegistered)
+
+	api.GET("/get-chat/:id", handler.GetChatByID)
+	api.POST("/llm-prompt/:id", handler.LLMChat)
+	api.POST("/llm-prompt/:id/stream", handler.LLMChatStream)
+	api.GET("/chats", handler.ListChats)
+	api.PATCH("/chats/:id", handler.UpdateChat)
+	api.DELETE("/chats/:id", handler.DeleteChat)
+	api.GET("/chats/:id/ws", handler.ChatWebSocket)
+	api.GET("/chats/:id/export", handler.ExportChat)
+	api.POST("/chats/import", handler.ImportChat)
+	api.POST("/chats/:id/messages/:messageId/retry", handler.RetryMessage)
+	api.POST("/chats/:id/messages/:messageId/regenerate", handler.RegenerateMessage)
+	api.PUT("/chats/:id/messages/:messageId", handler.EditMessage)
+	api.POST("/chats/:id/messages/:messageId/activate", handler.ActivateMessage)
+	api.GET("/search", handler.Search)
+
+	api.POST("/eligibility", handler.CheckEligibility)
+	api.POST("/calculators/schedule", handler.CalculateSchedule)
+
+	api.GET("/goals", handler.ListGoals)
+	api.POST("/goals", handler.CreateGoal)
+	api.GET("/goals/:id", handler.GetGoal)
+	api.PATCH("/goals/:id", handler.UpdateGoal)
+	api.DELETE("/goals/:id", handler.DeleteGoal)
+	api.POST("/goals/:id/actions", handler.CreateGoalAction)
+	api.PATCH("/goals/:id/actions/:actionId", handler.UpdateGoalAction)
+	api.POST("/goals/:id/actions/:actionId/complete", handler.CompleteGoalAction)
+	api.DELETE("/goals/:id/actions/:actionId", handler.DeleteGoalAction)
+
+	// Admin
+	admin := api.Group("/admin", auth.RequireAdmin)
+
+	admin.GET("/products", handler.ListProducts)
+	admin.POST("/products", handler.CreateProduct)
+	admin.GET("/products/:id", handler.GetProduct)
+	admin.PUT("/products/:id", handler.UpdateProduct)
+	admin.DELETE("/products/:id", handler.DeleteProduct)
+	admin.GET("/llm/stats", handler.LLMStats)
 
 }
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"backend/models"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrEmailTaken         = errors.New("email is already registered")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrInvalidGuestToken  = errors.New("invalid or expired guest token")
+)
+
+func (s *service) Register(ctx context.Context, req *models.RegisterRequest) (*models.AuthResponse, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Email:        strings.TrimSpace(req.Email),
+		Name:         strings.TrimSpace(req.Name),
+		PasswordHash: string(hash),
+	}
+	if err := s.repo.CreateUser(ctx, user); err != nil {
+		if errors.Is(err, models.ErrAlreadyExists) {
+			return nil, ErrEmailTaken
+		}
+		return nil, err
+	}
+
+	return s.issueTokenAndClaim(ctx, user, req.GuestToken)
+}
+
+func (s *service) Login(ctx context.Context, req *models.LoginRequest) (*models.AuthResponse, error) {
+	user, err := s.repo.GetUserByEmail(ctx, strings.TrimSpace(req.Email))
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	return s.issueTokenAndClaim(ctx, user, req.GuestToken)
+}
+
+func (s *service) GetUser(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	return s.repo.GetUserByID(ctx, userID)
+}
+
+// issueTokenAndClaim signs a token for user and, if the caller was browsing
+// as a guest, moves the guest's chats over. A stale or foreign guest token
+// must not block the login itself, so it is ignored.
+func (s *service) issueTokenAndClaim(ctx context.Context, user *models.User, guestToken string) (*models.AuthResponse, error) {
+	token, expiresAt, err := s.tokens.Issue(user.ID, user.IsAdmin)
+	if err != nil {
+		return nil, err
+	}
+	resp := &models.AuthResponse{Token: token, ExpiresAt: expiresAt, User: user}
+
+	if guestToken != "" {
+		claimed, err := s.ClaimGuestChats(ctx, user.ID, guestToken)
+		if err != nil && !errors.Is(err, ErrInvalidGuestToken) {
+			return nil, err
+		}
+		resp.ClaimedChats = claimed
+	}
+	return resp, nil
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"backend/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNotRegenerable = errors.New("only assistant replies can be regenerated")
+	ErrNotEditable    = errors.New("only user messages can be edited")
+	ErrReplyPending   = errors.New("the reply is still being generated")
+)
+
+// branchTo returns the path from the root of tree to the message id, or nil
+// if there is no such message.
+func branchTo(tree []models.Message, id uuid.UUID) []models.Message {
+	byID := make(map[uuid.UUID]*models.Message, len(tree))
+	for i := range tree {
+		byID[tree[i].ID] = &tree[i]
+	}
+	var path []models.Message
+	for m := byID[id]; m != nil; {
+		path = append(path, *m)
+		if m.ParentID == nil {
+			break
+		}
+		m = byID[*m.ParentID]
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// latestLeaf follows the newest reply below id down to the end of its
+// branch.
+func latestLeaf(tree []models.Message, id uuid.UUID) uuid.UUID {
+	newest := make(map[uuid.UUID]*models.Message, len(tree))
+	for i := range tree {
+		m := &tree[i]
+		if m.ParentID == nil {
+			continue
+		}
+		if cur := newest[*m.ParentID]; cur == nil || !m.CreatedAt.Before(cur.CreatedAt) {
+			newest[*m.ParentID] = m
+		}
+	}
+	for child := newest[id]; child != nil; child = newest[id] {
+		id = child.ID
+	}
+	return id
+}
+
+// ActivateMessage makes the branch through messageID the chat's active one
+// and returns the chat with it. The branch runs on to the newest reply below
+// the message, so picking an earlier sibling shows that conversation as it
+// was left.
+func (s *service) ActivateMessage(ctx context.Context, userID, chatID, messageID uuid.UUID) (*models.Chat, error) {
+	chat, err := s.GetChatTree(ctx, userID, chatID)
+	if err != nil {
+		return nil, err
+	}
+	if len(branchTo(chat.Messages, messageID)) == 0 {
+		return nil, models.ErrNotFound
+	}
+	if err := s.repo.SetActiveLeaf(ctx, chatID, latestLeaf(chat.Messages, messageID)); err != nil {
+		return nil, err
+	}
+	return s.GetChatByID(ctx, userID, chatID)
+}
+
+// RegenerateMessage answers the question of an assistant reply again. The new
+// reply is a sibling of the old one and ends the active branch; the old reply
+// and everything that followed it stay in the tree.
+func (s *service) RegenerateMessage(ctx context.Context, userID, chatID, messageID uuid.UUID, opts models.ReplyOptions) (*models.Message, error) {
+	chat, err := s.GetChatTree(ctx, userID, chatID)
+	if err != nil {
+		return nil, err
+	}
+	path := branchTo(chat.Messages, messageID)
+	if len(path) == 0 {
+		return nil, models.ErrNotFound
+	}
+	target := path[len(path)-1]
+	if target.Role != "assistant" || target.IsToolStep() {
+		return nil, ErrNotRegenerable
+	}
+	if target.Status == models.MessageStatusPending {
+		return nil, ErrReplyPending
+	}
+	question := len(path) - 1
+	for question >= 0 && path[question].Role != "user" {
+		question--
+	}
+	if question < 0 {
+		return nil, ErrNotRegenerable
+	}
+
+	request := &path[question]
+	pending, err := s.startTurn(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	history := *chat
+	history.Messages = path[:question]
+	return s.runTurn(ctx, pending, request, &history, opts)
+}
+
+// EditMessage sends content in place of an earlier user message. The edited
+// message becomes its sibling, starting a new active branch, and is answered
+// from the history before it.
+func (s *service) EditMessage(ctx context.Context, userID, chatID, messageID uuid.UUID, content string, opts models.ReplyOptions) (*models.Turn, error) {
+	chat, err := s.GetChatTree(ctx, userID, chatID)
+	if err != nil {
+		return nil, err
+	}
+	path := branchTo(chat.Messages, messageID)
+	if len(path) == 0 {
+		return nil, models.ErrNotFound
+	}
+	target := path[len(path)-1]
+	if target.Role != "user" {
+		return nil, ErrNotEditable
+	}
+
+	request := &models.Message{ChatID: chatID, ParentID: target.ParentID, Role: "user", Content: content}
+	pending, err := s.startTurn(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	history := *chat
+	history.Messages = path[:len(path)-1]
+	reply, err := s.runTurn(ctx, pending, request, &history, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Turn{Request: request, Reply: reply}, nil
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"backend/models"
+
+	"github.com/google/uuid"
+)
+
+// testTree builds messages from name → parent name pairs, in order; the
+// names are kept in Content.
+func testTree(pairs ...string) ([]models.Message, map[string]uuid.UUID) {
+	ids := make(map[string]uuid.UUID)
+	var tree []models.Message
+	start := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < len(pairs); i += 2 {
+		name, parent := pairs[i], pairs[i+1]
+		ids[name] = uuid.New()
+		m := models.Message{ID: ids[name], Content: name, CreatedAt: start.Add(time.Duration(i) * time.Second)}
+		if parent != "" {
+			p := ids[parent]
+			m.ParentID = &p
+		}
+		tree = append(tree, m)
+	}
+	return tree, ids
+}
+
+func contents(msgs []models.Message) []string {
+	out := make([]string, len(msgs))
+	for i, m := range msgs {
+		out[i] = m.Content
+	}
+	return out
+}
+
+func TestBranchTo(t *testing.T) {
+	tree, ids := testTree(
+		"q1", "",
+		"a1", "q1",
+		"a1'", "q1", // regenerated
+		"q2", "a1",
+		"q2'", "a1", // edited
+		"a2'", "q2'",
+	)
+	tests := []struct {
+		to   string
+		want []string
+	}{
+		{"q1", []string{"q1"}},
+		{"a1'", []string{"q1", "a1'"}},
+		{"q2", []string{"q1", "a1", "q2"}},
+		{"a2'", []string{"q1", "a1", "q2'", "a2'"}},
+		{"missing", nil},
+	}
+	for _, tt := range tests {
+		if got := contents(branchTo(tree, ids[tt.to])); !slices.Equal(got, tt.want) {
+			t.Errorf("branchTo(%s) = %v, want %v", tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestLatestLeaf(t *testing.T) {
+	tree, ids := testTree(
+		"q1", "",
+		"a1", "q1",
+		"q2", "a1",
+		"a2", "q2",
+		"a1'", "q1",
+		"q2'", "a1",
+	)
+	tests := []struct{ from, want string }{
+		{"a2", "a2"},
+		{"q2", "a2"},
+		{"a1", "q2'"}, // the edited question is newer than q2
+		{"q1", "a1'"},
+	}
+	for _, tt := range tests {
+		if got := latestLeaf(tree, ids[tt.from]); got != ids[tt.want] {
+			t.Errorf("latestLeaf(%s) = %v, want %s", tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestSummaryFits(t *testing.T) {
+	tree, ids := testTree("q1", "", "a1", "q1", "q2", "a1", "a1'", "q1")
+	branch := branchTo(tree, ids["q2"])
+	tests := []struct {
+		name    string
+		covered []string
+		want    bool
+	}{
+		{"nothing covered", nil, true},
+		{"prefix of the branch", []string{"q1", "a1"}, true},
+		{"another branch", []string{"q1", "a1'"}, false},
+	}
+	for _, tt := range tests {
+		sum := &models.ChatSummary{}
+		for _, name := range tt.covered {
+			sum.MessageIDs = append(sum.MessageIDs, ids[name])
+		}
+		if got := summaryFits(sum, branch); got != tt.want {
+			t.Errorf("%s: summaryFits = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// branchChat is system → q1 → a1 → q2 → a2, with a2 the active leaf.
+func branchChat(repo *memRepo) (*models.Chat, []models.Message) {
+	chat := repo.addChat(uuid.New(), testModel,
+		models.Message{Role: "system", Content: "prompt"},
+		models.Message{Role: "user", Content: "q1"},
+		models.Message{Role: "assistant", Content: "a1"},
+		models.Message{Role: "user", Content: "q2"},
+		models.Message{Role: "assistant", Content: "a2"},
+	)
+	return chat, repo.branch(chat.ID)
+}
+
+func TestNewMessageContinuesActiveBranch(t *testing.T) {
+	s, repo, _ := newTestService(t)
+	chat, msgs := branchChat(repo)
+	full, err := s.GetChatByID(context.Background(), chat.UserID, chat.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := &models.Message{ChatID: chat.ID, Role: "user", Content: "q3"}
+	if _, err := s.LLMRequestAndSave(context.Background(), request, full, models.ReplyOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if request.ParentID == nil || *request.ParentID != msgs[4].ID {
+		t.Errorf("request parent = %v, want a2 %s", request.ParentID, msgs[4].ID)
+	}
+}
+
+func TestRegenerateMessage(t *testing.T) {
+	s, repo, fake := newTestService(t)
+	chat, msgs := branchChat(repo)
+	q2, a2 := msgs[3], msgs[4]
+	fake.Push("a2'")
+
+	reply, err := s.RegenerateMessage(context.Background(), chat.UserID, chat.ID, a2.ID, models.ReplyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := repo.message(reply.ID)
+	if saved.ParentID == nil || *saved.ParentID != q2.ID {
+		t.Errorf("regenerated reply parent = %v, want q2 %s", saved.ParentID, q2.ID)
+	}
+	if got := contents(repo.branch(chat.ID)); !slices.Equal(got, []string{"prompt", "q1", "a1", "q2", "a2'"}) {
+		t.Errorf("active branch = %v, want it to end with the new reply", got)
+	}
+	// The model sees the history up to the question, and the question once.
+	sent := fake.Requests()
+	msgsSent := sent[len(sent)-1].Messages
+	if last := msgsSent[len(msgsSent)-1]; last.Content != "q2" {
+		t.Errorf("request ends with %q, want q2", last.Content)
+	}
+	if prev := msgsSent[len(msgsSent)-2]; prev.Content != "a1" {
+		t.Errorf("history before the question ends with %q, want a1", prev.Content)
+	}
+
+	if _, err := s.RegenerateMessage(context.Background(), chat.UserID, chat.ID, q2.ID, models.ReplyOptions{}); !errors.Is(err, ErrNotRegenerable) {
+		t.Errorf("regenerating a question: err = %v, want ErrNotRegenerable", err)
+	}
+}
+
+func TestRegeneratePendingReply(t *testing.T) {
+	s, repo, _ := newTestService(t)
+	chat := repo.addChat(uuid.New(), testModel,
+		models.Message{Role: "system", Content: "prompt"},
+		models.Message{Role: "user", Content: "q1"},
+		models.Message{Role: "assistant", Status: models.MessageStatusPending, CreatedAt: time.Now()},
+	)
+	_, err := s.RegenerateMessage(context.Background(), chat.UserID, chat.ID, *chat.ActiveLeafID, models.ReplyOptions{})
+	if !errors.Is(err, ErrReplyPending) {
+		t.Errorf("err = %v, want ErrReplyPending", err)
+	}
+}
+
+func TestEditMessage(t *testing.T) {
+	s, repo, fake := newTestService(t)
+	chat, msgs := branchChat(repo)
+	a1, q2 := msgs[2], msgs[3]
+	fake.Push("a2'")
+
+	turn, err := s.EditMessage(context.Background(), chat.UserID, chat.ID, q2.ID, "q2'", models.ReplyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, reply := repo.message(turn.Request.ID), repo.message(turn.Reply.ID)
+	if request.ParentID == nil || *request.ParentID != a1.ID {
+		t.Errorf("edited question parent = %v, want a1 %s", request.ParentID, a1.ID)
+	}
+	if reply.ParentID == nil || *reply.ParentID != request.ID {
+		t.Errorf("reply parent = %v, want the edited question %s", reply.ParentID, request.ID)
+	}
+	want := []string{"prompt", "q1", "a1", "q2'", "a2'"}
+	if got := contents(repo.branch(chat.ID)); !slices.Equal(got, want) {
+		t.Errorf("active branch = %v, want %v", got, want)
+	}
+
+	if _, err := s.EditMessage(context.Background(), chat.UserID, chat.ID, a1.ID, "x", models.ReplyOptions{}); !errors.Is(err, ErrNotEditable) {
+		t.Errorf("editing a reply: err = %v, want ErrNotEditable", err)
+	}
+}
+
+func TestActivateMessage(t *testing.T) {
+	s, repo, fake := newTestService(t)
+	chat, msgs := branchChat(repo)
+	q2 := msgs[3]
+	fake.Push("a2'")
+	if _, err := s.EditMessage(context.Background(), chat.UserID, chat.ID, q2.ID, "q2'", models.ReplyOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.ActivateMessage(context.Background(), chat.UserID, chat.ID, q2.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := contents(got.Messages); !slices.Equal(names, []string{"q1", "a1", "q2", "a2"}) {
+		t.Errorf("active branch = %v, want q1 a1 q2 a2", names)
+	}
+
+	other := repo.addChat(chat.UserID, testModel, models.Message{Role: "user", Content: "elsewhere"})
+	if _, err := s.ActivateMessage(context.Background(), chat.UserID, chat.ID, *other.ActiveLeafID); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("activating another chat's message: err = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,17 @@
+package services
+
+import (
+	"context"
+
+	"backend/finance"
+	"backend/models"
+)
+
+func (s *service) CalculateCushion(_ context.Context, req *models.CushionRequest) (*models.CushionPlan, error) {
+	plan := finance.Cushion(req)
+	return &plan, nil
+}
+
+func (s *service) CalculateSchedule(_ context.Context, req *models.ScheduleRequest) (*models.Schedule, error) {
+	return finance.BuildSchedule(req)
+}
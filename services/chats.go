@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"backend/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrEmptyTitle    = errors.New("title must not be empty")
+)
+
+func (s *service) ListChats(ctx context.Context, userID uuid.UUID, params models.ChatListParams) (*models.ChatPage, error) {
+	limit := params.Limit
+	if limit <= 0 {
+		limit = models.DefaultChatPageSize
+	}
+	if limit > models.MaxChatPageSize {
+		limit = models.MaxChatPageSize
+	}
+
+	var cursor *models.ChatCursor
+	if params.Cursor != "" {
+		c, err := decodeChatCursor(params.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		cursor = c
+	}
+
+	// Fetch one extra row to know whether another page exists.
+	chats, err := s.repo.ListChats(ctx, userID, limit+1, cursor, params.Archived)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.ChatPage{Chats: chats}
+	if len(chats) > limit {
+		page.Chats = chats[:limit]
+		last := page.Chats[limit-1]
+		page.NextCursor = encodeChatCursor(models.ChatCursor{LastMessageAt: last.LastMessageAt, ID: last.ID})
+	}
+	return page, nil
+}
+
+func (s *service) UpdateChat(ctx context.Context, userID, chatID uuid.UUID, req *models.UpdateChatRequest) (*models.Chat, error) {
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		if title == "" {
+			return nil, ErrEmptyTitle
+		}
+		req.Title = &title
+	}
+	return s.repo.UpdateChat(ctx, userID, chatID, req.Title, req.Archived)
+}
+
+func (s *service) DeleteChat(ctx context.Context, userID, chatID uuid.UUID) error {
+	return s.repo.DeleteChat(ctx, userID, chatID)
+}
+
+func encodeChatCursor(c models.ChatCursor) string {
+	raw := c.LastMessageAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeChatCursor(s string) (*models.ChatCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	at, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	chatID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &models.ChatCursor{LastMessageAt: at, ID: chatID}, nil
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"backend/llm"
+	"backend/models"
+
+	"github.com/google/uuid"
+)
+
+// chatModel is the model a chat was started with, or the default model if
+// that one has since been removed from the registry.
+func (s *service) chatModel(chat *models.Chat) string {
+	if _, ok := s.cfg.LLM.Model(chat.Model); ok {
+		return chat.Model
+	}
+	if chat.Model != "" {
+		log.Printf("Chat %s: model %s is not registered, using %s", chat.ID, chat.Model, s.cfg.LLM.DefaultModel)
+	}
+	return s.cfg.LLM.DefaultModel
+}
+
+// buildLLMRequest assembles the prompt for the next turn: the current system
+// prompt, the user's active goals, the chat summary in place of the messages
+// it covers, as much recent history as the model's token budget allows, and
+// the new message. The system message stored with the chat is only a record
+// of what the chat started with; the prompt is always rebuilt from the live
+// product catalog. Tool calls of earlier turns are left out: their outcome is
+// in the replies that followed, and dropping them keeps every request free of
+// orphaned results.
+func (s *service) buildLLMRequest(ctx context.Context, requestMessage *models.Message, fullChat *models.Chat) (*llm.ChatRequest, error) {
+	model := s.chatModel(fullChat)
+
+	prompt, err := s.systemPrompt(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]llm.Message, 0, len(fullChat.Messages)+4)
+	messages = append(messages, llm.Message{Role: "system", Content: prompt})
+
+	if fullChat.UserID != uuid.Nil {
+		goals, err := s.goalsContext(ctx, fullChat.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if goals != "" {
+			messages = append(messages, llm.Message{Role: "system", Content: goals})
+		}
+	}
+
+	history := fullChat.Messages
+	for len(history) > 0 && history[0].Role == "system" {
+		history = history[1:]
+	}
+
+	covered := map[uuid.UUID]bool{}
+	if sum := fullChat.Summary; sum != nil && !sum.Stale && summaryFits(sum, history) {
+		messages = append(messages, llm.Message{Role: "system", Content: summaryPreamble + sum.Content})
+		for _, id := range sum.MessageIDs {
+			covered[id] = true
+		}
+	}
+	for _, m := range history {
+		if covered[m.ID] || m.IsToolStep() || m.Unfinished() {
+			continue
+		}
+		messages = append(messages, llm.Message{Role: m.Role, Content: messageText(&m)})
+	}
+	messages = append(messages, llm.Message{Role: requestMessage.Role, Content: requestMessage.Content})
+
+	fitted, dropped, err := s.counter.FitContext(model, messages, s.cfg.LLM.ContextBudgetFor(model))
+	if err != nil {
+		return nil, err
+	}
+	if dropped > 0 {
+		log.Printf("Chat %s: dropped %d oldest messages to fit the %s context budget", fullChat.ID, dropped, model)
+	}
+
+	return s.newChatRequest(model, fitted), nil
+}
+
+// newChatRequest applies the configured sampling settings.
+func (s *service) newChatRequest(model string, messages []llm.Message) *llm.ChatRequest {
+	return &llm.ChatRequest{
+		Model:       model,
+		Messages:    messages,
+		Temperature: s.cfg.LLM.Temperature,
+		MaxTokens:   s.cfg.LLM.MaxTokens,
+	}
+}
+
+// summaryFits reports whether every message sum covers is in msgs. A summary
+// made on another branch, or reaching past the point a reply is regenerated
+// from, would tell the model about turns it should not see.
+func summaryFits(sum *models.ChatSummary, msgs []models.Message) bool {
+	in := make(map[uuid.UUID]bool, len(msgs))
+	for _, m := range msgs {
+		in[m.ID] = true
+	}
+	for _, id := range sum.MessageIDs {
+		if !in[id] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,17 @@
+package services
+
+import (
+	"context"
+
+	"backend/eligibility"
+	"backend/models"
+)
+
+// CheckEligibility matches the client against the active catalog.
+func (s *service) CheckEligibility(ctx context.Context, req *models.EligibilityRequest) (*models.EligibilityResult, error) {
+	products, err := s.repo.ListProducts(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	return eligibility.Evaluate(products, req), nil
+}
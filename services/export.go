@@ -0,0 +1,243 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"backend/models"
+	"backend/repositories"
+
+	"github.com/google/uuid"
+)
+
+var ErrUnknownExportFormat = errors.New("format must be md, json or html")
+
+const exportTimeLayout = "2006-01-02 15:04 UTC"
+
+// ExportChat renders the active branch of a chat as a file in format.
+func (s *service) ExportChat(ctx context.Context, userID, chatID uuid.UUID, format string) (*models.ExportFile, error) {
+	chat, err := s.GetChatByID(ctx, userID, chatID)
+	if err != nil {
+		return nil, err
+	}
+	chat.Summary = nil
+	now := time.Now().UTC()
+	name := "chat-" + chat.ID.String() + "." + format
+
+	switch format {
+	case models.ExportJSON:
+		body, err := json.MarshalIndent(models.ChatExport{Version: models.ChatExportVersion, ExportedAt: now, Chat: chat}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return &models.ExportFile{Name: name, ContentType: "application/json; charset=utf-8", Body: body}, nil
+	case models.ExportMarkdown:
+		return &models.ExportFile{Name: name, ContentType: "text/markdown; charset=utf-8", Body: exportMarkdown(chat, now)}, nil
+	case models.ExportHTML:
+		body, err := exportHTML(chat, now)
+		if err != nil {
+			return nil, err
+		}
+		return &models.ExportFile{Name: name, ContentType: "text/html; charset=utf-8", Body: body}, nil
+	default:
+		return nil, ErrUnknownExportFormat
+	}
+}
+
+var ErrInvalidImport = errors.New("not a chat export")
+
+// ImportChat restores a JSON export as a new chat of userID. Messages keep
+// their order, content, tool calls and status but get new IDs and the time of
+// the import; replies that were still pending are left out. Like a new chat
+// it starts from the current system prompt, and a model that is no longer
+// registered is replaced by the default one.
+func (s *service) ImportChat(ctx context.Context, userID uuid.UUID, export *models.ChatExport) (*models.Chat, error) {
+	if export.Chat == nil {
+		return nil, ErrInvalidImport
+	}
+	if export.Version != models.ChatExportVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidImport, export.Version)
+	}
+	model, err := s.ResolveModel(export.Chat.Model)
+	if err != nil {
+		model = s.cfg.LLM.DefaultModel
+	}
+	prompt, err := s.systemPrompt(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	chatID := uuid.New()
+	err = s.repo.WithTx(ctx, func(repo repositories.Repository) error {
+		if err := repo.CreateNewChat(ctx, userID, chatID, export.Chat.Title, model); err != nil {
+			return err
+		}
+		parent := &models.Message{ChatID: chatID, Role: "system", Content: prompt}
+		if err := repo.SaveMessage(ctx, parent); err != nil {
+			return err
+		}
+		for _, m := range export.Chat.Messages {
+			if m.Status == models.MessageStatusPending {
+				continue
+			}
+			if err := checkImported(&m); err != nil {
+				return err
+			}
+			m.ID, m.ChatID, m.ParentID = uuid.Nil, chatID, &parent.ID
+			if err := repo.SaveMessage(ctx, &m); err != nil {
+				return err
+			}
+			parent = &m
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetChatByID(ctx, userID, chatID)
+}
+
+func checkImported(m *models.Message) error {
+	switch m.Role {
+	case "user", "assistant", "tool":
+	default:
+		return fmt.Errorf("%w: unexpected role %q", ErrInvalidImport, m.Role)
+	}
+	switch m.Status {
+	case "", models.MessageStatusCompleted, models.MessageStatusFailed, models.MessageStatusCancelled:
+	default:
+		return fmt.Errorf("%w: unexpected status %q", ErrInvalidImport, m.Status)
+	}
+	return nil
+}
+
+// transcriptEntry is a message as the readable exports show it. Tool steps
+// and replies still being generated are left out.
+type transcriptEntry struct {
+	Role  string // "user" or "assistant"
+	Label string
+	At    string
+	Text  string
+	Note  string // why a reply is incomplete, if it is
+}
+
+func transcript(chat *models.Chat) []transcriptEntry {
+	var out []transcriptEntry
+	for _, m := range chat.Messages {
+		if m.IsToolStep() || m.Status == models.MessageStatusPending {
+			continue
+		}
+		e := transcriptEntry{Role: m.Role, Label: roleLabel(m.Role), At: m.CreatedAt.UTC().Format(exportTimeLayout), Text: messageText(&m)}
+		if m.Status == models.MessageStatusFailed || m.Status == models.MessageStatusCancelled {
+			e.Note = "Reply " + m.Status
+			if m.Error != "" {
+				e.Note += " (" + m.Error + ")"
+			}
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func roleLabel(role string) string {
+	switch role {
+	case "user":
+		return "User"
+	case "assistant":
+		return "Assistant"
+	default:
+		return role
+	}
+}
+
+func exportTitle(chat *models.Chat) string {
+	if chat.Title != "" {
+		return chat.Title
+	}
+	return "Chat " + chat.ID.String()
+}
+
+// markdownEscaper keeps a user-written title from turning into markup.
+var markdownEscaper = strings.NewReplacer(
+	"\\", "\\\\", "`", "\\`", "*", "\\*", "_", "\\_", "[", "\\[", "]", "\\]",
+	"<", "\\<", ">", "\\>", "#", "\\#", "|", "\\|", "~", "\\~",
+	"\r", " ", "\n", " ",
+)
+
+// exportMarkdown puts every message in a blockquote: replies are Markdown
+// themselves, and quoted their headings and rules cannot be mistaken for the
+// transcript's own.
+func exportMarkdown(chat *models.Chat, now time.Time) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", markdownEscaper.Replace(exportTitle(chat)))
+	fmt.Fprintf(&b, "_Started %s, exported %s._\n", chat.CreatedAt.UTC().Format(exportTimeLayout), now.Format(exportTimeLayout))
+	for _, e := range transcript(chat) {
+		fmt.Fprintf(&b, "\n## %s · %s\n\n", e.Label, e.At)
+		if text := strings.TrimSpace(e.Text); text != "" {
+			for _, line := range strings.Split(text, "\n") {
+				b.WriteString(strings.TrimRight("> "+line, " "))
+				b.WriteString("\n")
+			}
+		}
+		if e.Note != "" {
+			fmt.Fprintf(&b, "\n_%s._\n", e.Note)
+		}
+	}
+	return []byte(b.String())
+}
+
+// exportTemplate is styled to be printed or saved as PDF from a browser.
+var exportTemplate = template.Must(template.New("export").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+  body { font: 14px/1.5 -apple-system, "Segoe UI", Roboto, Arial, sans-serif; color: #222; max-width: 760px; margin: 2em auto; padding: 0 1em; }
+  h1 { font-size: 22px; margin-bottom: 0.2em; }
+  .meta { color: #666; font-size: 12px; margin-bottom: 2em; }
+  .message { border-left: 3px solid #ccc; padding: 0.2em 0 0.2em 1em; margin: 0 0 1.2em; page-break-inside: avoid; break-inside: avoid; }
+  .message.assistant { border-color: #2e7d5b; }
+  .role { font-weight: 600; }
+  .at { color: #666; font-size: 12px; margin-left: 0.5em; }
+  .text { white-space: pre-wrap; margin-top: 0.3em; }
+  .note { color: #a33; font-style: italic; font-size: 12px; }
+  @page { size: A4; margin: 18mm; }
+  @media print { body { margin: 0; max-width: none; } }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="meta">Started {{.Started}} · exported {{.Exported}}</div>
+{{range .Entries}}<div class="message {{.Role}}">
+  <div><span class="role">{{.Label}}</span><span class="at">{{.At}}</span></div>
+  {{if .Text}}<div class="text">{{.Text}}</div>{{end}}
+  {{if .Note}}<div class="note">{{.Note}}</div>{{end}}
+</div>
+{{end}}</body>
+</html>
+`))
+
+func exportHTML(chat *models.Chat, now time.Time) ([]byte, error) {
+	data := struct {
+		Title, Started, Exported string
+		Entries                  []transcriptEntry
+	}{
+		Title:    exportTitle(chat),
+		Started:  chat.CreatedAt.UTC().Format(exportTimeLayout),
+		Exported: now.Format(exportTimeLayout),
+		Entries:  transcript(chat),
+	}
+
+	var buf bytes.Buffer
+	if err := exportTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
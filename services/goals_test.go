@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"backend/models"
+
+	"github.com/google/uuid"
+)
+
+// chainGoal has actions a ← b ← c, each depending on the previous one, and a
+// free action d.
+func chainGoal() (*models.Goal, map[string]uuid.UUID) {
+	ids := map[string]uuid.UUID{"a": uuid.New(), "b": uuid.New(), "c": uuid.New(), "d": uuid.New()}
+	dep := func(name string) *uuid.UUID { return ptr(ids[name]) }
+	return &models.Goal{
+		UserID:       uuid.New(),
+		Title:        "Подушка",
+		TargetAmount: 1000,
+		Actions: []models.GoalAction{
+			{ID: ids["a"], Description: "a", Amount: 100},
+			{ID: ids["b"], Description: "b", Amount: 100, DependsOn: dep("a")},
+			{ID: ids["c"], Description: "c", Amount: 100, DependsOn: dep("b")},
+			{ID: ids["d"], Description: "d", Amount: 100},
+		},
+	}, ids
+}
+
+func ptr(id uuid.UUID) *uuid.UUID { return &id }
+
+func TestCheckDependency(t *testing.T) {
+	goal, ids := chainGoal()
+	foreign := uuid.New()
+	tests := []struct {
+		name      string
+		action    string // "" for a new action
+		dependsOn *uuid.UUID
+		wantErr   bool
+	}{
+		{"no dependency", "a", nil, false},
+		{"new action on a chain", "", ptr(ids["c"]), false},
+		{"free action on a chain", "d", ptr(ids["c"]), false},
+		{"itself", "d", ptr(ids["d"]), true},
+		{"closes a cycle", "a", ptr(ids["c"]), true},
+		{"another goal's action", "d", &foreign, true},
+	}
+	for _, tt := range tests {
+		err := checkDependency(goal, ids[tt.action], tt.dependsOn)
+		if tt.wantErr != (err != nil) || (err != nil && !errors.Is(err, ErrInvalidGoal)) {
+			t.Errorf("%s: err = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestComputeProgress(t *testing.T) {
+	goal, ids := chainGoal()
+	goal.SavedAmount = 1500
+	goal.Actions[0].Status = models.ActionStatusDone
+	computeProgress(goal)
+
+	if goal.Progress != 100 || goal.ActionsDone != 1 || goal.ActionsTotal != 4 {
+		t.Errorf("progress %d%%, %d of %d actions done; want 100%%, 1 of 4", goal.Progress, goal.ActionsDone, goal.ActionsTotal)
+	}
+	blocked := map[uuid.UUID]bool{ids["c"]: true}
+	for _, a := range goal.Actions {
+		if a.Blocked != blocked[a.ID] {
+			t.Errorf("action %s blocked = %v, want %v", a.Description, a.Blocked, blocked[a.ID])
+		}
+	}
+}
+
+func TestCompleteBlockedAction(t *testing.T) {
+	s, repo, _ := newTestService(t)
+	seed, ids := chainGoal()
+	goal := repo.addGoal(*seed)
+	ctx := context.Background()
+
+	if _, err := s.CompleteGoalAction(ctx, goal.UserID, goal.ID, ids["b"]); !errors.Is(err, ErrActionBlocked) {
+		t.Fatalf("completing b before a: err = %v, want ErrActionBlocked", err)
+	}
+	if _, err := s.UpdateGoalAction(ctx, goal.UserID, goal.ID, ids["b"], &models.UpdateGoalActionRequest{ClearDependsOn: true}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.CompleteGoalAction(ctx, goal.UserID, goal.ID, ids["b"])
+	if err != nil {
+		t.Fatalf("completing b once its dependency is cleared: %v", err)
+	}
+	if got.SavedAmount != 100 || got.Actions[1].Status != models.ActionStatusDone || got.Actions[1].DependsOn != nil {
+		t.Errorf("goal after completing b = %+v", got)
+	}
+}
+
+func TestUpdateGoalActionRejectsCycle(t *testing.T) {
+	s, repo, _ := newTestService(t)
+	seed, ids := chainGoal()
+	goal := repo.addGoal(*seed)
+
+	_, err := s.UpdateGoalAction(context.Background(), goal.UserID, goal.ID, ids["a"], &models.UpdateGoalActionRequest{DependsOn: ptr(ids["c"])})
+	if !errors.Is(err, ErrInvalidGoal) {
+		t.Errorf("err = %v, want ErrInvalidGoal", err)
+	}
+}
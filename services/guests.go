@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"backend/models"
+
+	"github.com/google/uuid"
+)
+
+// StartGuestSession creates a throwaway guest account so that people can try
+// the coach before signing up. The returned token expires with the account.
+func (s *service) StartGuestSession(ctx context.Context) (*models.AuthResponse, error) {
+	expiresAt := time.Now().Add(s.cfg.Auth.GuestTTL)
+	guest, err := s.repo.CreateGuestUser(ctx, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.tokens.IssueGuest(guest.ID, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AuthResponse{Token: token, ExpiresAt: expiresAt, User: guest}, nil
+}
+
+// DiscardGuestSession deletes a guest whose token never reached the client,
+// such as one started by a request that then failed.
+func (s *service) DiscardGuestSession(ctx context.Context, guestID uuid.UUID) error {
+	return s.repo.DeleteGuest(ctx, guestID)
+}
+
+// ClaimGuestChats transfers the chats of the guest identified by guestToken
+// to userID and deletes the guest.
+func (s *service) ClaimGuestChats(ctx context.Context, userID uuid.UUID, guestToken string) (int64, error) {
+	claims, err := s.tokens.Parse(guestToken)
+	if err != nil || !claims.Guest {
+		return 0, ErrInvalidGuestToken
+	}
+
+	moved, err := s.repo.ClaimGuestChats(ctx, claims.UserID(), userID)
+	if errors.Is(err, models.ErrNotFound) {
+		// Already claimed or cleaned up.
+		return 0, ErrInvalidGuestToken
+	}
+	return moved, err
+}
+
+func (s *service) CleanupExpiredGuests(ctx context.Context) (int64, error) {
+	return s.repo.DeleteExpiredGuests(ctx)
+}
+
+// RunGuestCleanup deletes expired guests every interval until ctx is done.
+func RunGuestCleanup(ctx context.Context, s Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.CleanupExpiredGuests(ctx)
+			if err != nil {
+				log.Printf("Guest cleanup failed: %v", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("Guest cleanup removed %d expired guests", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"backend/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidProduct = errors.New("invalid product")
+	ErrProductExists  = errors.New("product code is already used")
+)
+
+func (s *service) ListProducts(ctx context.Context, activeOnly bool) ([]models.Product, error) {
+	return s.repo.ListProducts(ctx, activeOnly)
+}
+
+func (s *service) GetProduct(ctx context.Context, id uuid.UUID) (*models.Product, error) {
+	return s.repo.GetProduct(ctx, id)
+}
+
+func (s *service) CreateProduct(ctx context.Context, req *models.ProductRequest) (*models.Product, error) {
+	p, err := productFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.repo.CreateProduct(ctx, p)
+	if errors.Is(err, models.ErrAlreadyExists) {
+		return nil, ErrProductExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.prompt.invalidate()
+	return created, nil
+}
+
+func (s *service) UpdateProduct(ctx context.Context, id uuid.UUID, req *models.ProductRequest) (*models.Product, error) {
+	p, err := productFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	p.ID = id
+
+	updated, err := s.repo.UpdateProduct(ctx, p)
+	if errors.Is(err, models.ErrAlreadyExists) {
+		return nil, ErrProductExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.prompt.invalidate()
+	return updated, nil
+}
+
+func (s *service) DeleteProduct(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.DeleteProduct(ctx, id); err != nil {
+		return err
+	}
+	s.prompt.invalidate()
+	return nil
+}
+
+// productFromRequest applies defaults and the cross-field checks the
+// validator tags cannot express.
+func productFromRequest(req *models.ProductRequest) (*models.Product, error) {
+	p := &models.Product{
+		Code:               strings.TrimSpace(req.Code),
+		Name:               strings.TrimSpace(req.Name),
+		Segment:            req.Segment,
+		Description:        strings.TrimSpace(req.Description),
+		MinAmount:          req.MinAmount,
+		MaxAmount:          req.MaxAmount,
+		MinTerm:            req.MinTerm,
+		MaxTerm:            req.MaxTerm,
+		TermUnit:           req.TermUnit,
+		MinAge:             req.MinAge,
+		MaxAge:             req.MaxAge,
+		ExpectedYield:      req.ExpectedYield,
+		Fees:               strings.TrimSpace(req.Fees),
+		Purposes:           req.Purposes,
+		RequiresCollateral: req.RequiresCollateral,
+		Active:             true,
+		SortOrder:          req.SortOrder,
+	}
+	if p.Purposes == nil {
+		p.Purposes = []string{}
+	}
+	if p.TermUnit == "" {
+		p.TermUnit = models.TermUnitMonth
+	}
+	if req.Active != nil {
+		p.Active = *req.Active
+	}
+
+	if p.MinAmount != nil && p.MaxAmount != nil && *p.MinAmount > *p.MaxAmount {
+		return nil, errors.Join(ErrInvalidProduct, errors.New("min_amount exceeds max_amount"))
+	}
+	if p.MinTerm != nil && p.MaxTerm != nil && *p.MinTerm > *p.MaxTerm {
+		return nil, errors.Join(ErrInvalidProduct, errors.New("min_term exceeds max_term"))
+	}
+	if p.MinAge != nil && p.MaxAge != nil && *p.MinAge > *p.MaxAge {
+		return nil, errors.Join(ErrInvalidProduct, errors.New("min_age exceeds max_age"))
+	}
+	return p, nil
+}
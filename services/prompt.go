@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/eligibility"
+	"backend/models"
+)
+
+// The catalog changes rarely; caching the rendered prompt keeps a products
+// query off every turn while bounding staleness across instances.
+const promptCacheTTL = time.Minute
+
+type promptCache struct {
+	mu       sync.Mutex
+	prompt   string
+	loadedAt time.Time
+}
+
+func (c *promptCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadedAt = time.Time{}
+}
+
+// systemPrompt returns BasePromptTemplate with the current product catalog
+// filled in.
+func (s *service) systemPrompt(ctx context.Context) (string, error) {
+	s.prompt.mu.Lock()
+	defer s.prompt.mu.Unlock()
+
+	if !s.prompt.loadedAt.IsZero() && time.Since(s.prompt.loadedAt) < promptCacheTTL {
+		return s.prompt.prompt, nil
+	}
+
+	products, err := s.repo.ListProducts(ctx, true)
+	if err != nil {
+		return "", fmt.Errorf("load product catalog: %w", err)
+	}
+
+	s.prompt.prompt = strings.Replace(models.BasePromptTemplate, models.ProductsPlaceholder, renderCatalog(products), 1)
+	s.prompt.loadedAt = time.Now()
+	return s.prompt.prompt, nil
+}
+
+func renderCatalog(products []models.Product) string {
+	var retail, business strings.Builder
+	for _, p := range products {
+		b := &retail
+		if p.Segment == models.SegmentBusiness {
+			b = &business
+		}
+		b.WriteString(renderProduct(p))
+		b.WriteString("\n\n")
+	}
+
+	var out strings.Builder
+	if retail.Len() > 0 {
+		out.WriteString("Розничные продукты (физлица):\n\n")
+		out.WriteString(retail.String())
+	}
+	if business.Len() > 0 {
+		out.WriteString("Бизнес-продукты (юрлица/ИП):\n\n")
+		out.WriteString(business.String())
+	}
+	return strings.TrimSpace(out.String())
+}
+
+func renderProduct(p models.Product) string {
+	bounds := func(min, max *int64, format func(int64) string) string {
+		return eligibility.FormatRange(min, max, format, eligibility.RangeRussian)
+	}
+	var limits []string
+	if p.ExpectedYield != nil {
+		limits = append(limits, "ожидаемая доходность до ~"+strconv.FormatFloat(*p.ExpectedYield, 'f', -1, 64)+"%")
+	}
+	if r := bounds(p.MinAmount, p.MaxAmount, eligibility.FormatTenge); r != "" {
+		limits = append(limits, "сумма "+r+" ₸")
+	}
+	unit := "мес"
+	if p.TermUnit == models.TermUnitDay {
+		unit = "дней"
+	}
+	if r := bounds(eligibility.Int64Ptr(p.MinTerm), eligibility.Int64Ptr(p.MaxTerm), eligibility.FormatInt); r != "" {
+		limits = append(limits, "срок "+r+" "+unit)
+	}
+	if r := bounds(eligibility.Int64Ptr(p.MinAge), eligibility.Int64Ptr(p.MaxAge), eligibility.FormatInt); r != "" {
+		limits = append(limits, "возраст "+r)
+	}
+
+	lines := []string{p.Name}
+	if len(limits) > 0 {
+		lines = append(lines, strings.Join(limits, ", ")+".")
+	}
+	if p.Fees != "" {
+		lines = append(lines, "Условия: "+p.Fees)
+	}
+	if p.Description != "" {
+		lines = append(lines, p.Description)
+	}
+	return strings.Join(lines, "\n")
+}
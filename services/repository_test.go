@@ -0,0 +1,316 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"backend/models"
+	"backend/repositories"
+
+	"github.com/google/uuid"
+)
+
+// memRepo is an in-memory Repository for service tests. It keeps chats and
+// messages the way the SQL does, including the active branch and the
+// messages.parent_id ON DELETE SET NULL; chats.active_leaf_id is not a
+// foreign key there either, so deleting the leaf leaves it dangling. Methods a
+// test does not need are left to the embedded nil interface and panic if
+// called. WithTx does not roll back.
+type memRepo struct {
+	repositories.Repository
+
+	mu       sync.Mutex
+	chats    map[uuid.UUID]*models.Chat
+	messages []models.Message // in insertion order
+	products []models.Product
+	goals    map[uuid.UUID]*models.Goal
+}
+
+func newMemRepo() *memRepo {
+	return &memRepo{chats: make(map[uuid.UUID]*models.Chat), goals: make(map[uuid.UUID]*models.Goal)}
+}
+
+func (r *memRepo) WithTx(ctx context.Context, fn func(repositories.Repository) error) error {
+	return fn(r)
+}
+
+// addChat stores a chat with msgs chained one below the other, the last one
+// being the active leaf. IDs are assigned where missing.
+func (r *memRepo) addChat(userID uuid.UUID, model string, msgs ...models.Message) *models.Chat {
+	chat := &models.Chat{ID: uuid.New(), UserID: userID, Model: model}
+	r.chats[chat.ID] = chat
+	var parent *uuid.UUID
+	for _, m := range msgs {
+		if m.ID == uuid.Nil {
+			m.ID = uuid.New()
+		}
+		if m.Status == "" {
+			m.Status = models.MessageStatusCompleted
+		}
+		m.ChatID = chat.ID
+		m.ParentID = parent
+		r.messages = append(r.messages, m)
+		parent = &m.ID
+	}
+	chat.ActiveLeafID = parent
+	return chat
+}
+
+func (r *memRepo) CreateNewChat(ctx context.Context, userID, id uuid.UUID, chatName, model string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.chats[id] = &models.Chat{ID: id, UserID: userID, Title: chatName, Model: model}
+	return nil
+}
+
+func (r *memRepo) UpdateChatTitle(ctx context.Context, id uuid.UUID, title string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.chats[id].Title = title
+	return nil
+}
+
+func (r *memRepo) GetChatAndMessages(ctx context.Context, userID, id uuid.UUID, tree bool) (*models.Chat, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	chat, ok := r.chats[id]
+	if !ok || chat.UserID != userID {
+		return nil, models.ErrNotFound
+	}
+	out := *chat
+	if tree {
+		out.Messages = r.chatMessages(id)
+	} else {
+		out.Messages = r.branch(id)
+	}
+	return &out, nil
+}
+
+func (r *memRepo) ListMessages(ctx context.Context, chatID uuid.UUID) ([]models.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.chatMessages(chatID), nil
+}
+
+func (r *memRepo) ListBranch(ctx context.Context, chatID uuid.UUID) ([]models.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.branch(chatID), nil
+}
+
+func (r *memRepo) chatMessages(chatID uuid.UUID) []models.Message {
+	var out []models.Message
+	for _, m := range r.messages {
+		if m.ChatID == chatID {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// branch walks from the active leaf up to the root.
+func (r *memRepo) branch(chatID uuid.UUID) []models.Message {
+	var out []models.Message
+	for id := r.chats[chatID].ActiveLeafID; id != nil; {
+		i := r.index(*id)
+		if i < 0 {
+			break
+		}
+		out = append(out, r.messages[i])
+		id = r.messages[i].ParentID
+	}
+	slices.Reverse(out)
+	return out
+}
+
+func (r *memRepo) index(id uuid.UUID) int {
+	return slices.IndexFunc(r.messages, func(m models.Message) bool { return m.ID == id })
+}
+
+// message returns a copy of the stored message id.
+func (r *memRepo) message(id uuid.UUID) models.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.messages[r.index(id)]
+}
+
+func (r *memRepo) SaveMessage(ctx context.Context, m *models.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	chat, ok := r.chats[m.ChatID]
+	if !ok {
+		return models.ErrNotFound
+	}
+	if m.Status == "" {
+		m.Status = models.MessageStatusCompleted
+	}
+	m.ID = uuid.New()
+	m.CreatedAt = time.Now()
+	r.messages = append(r.messages, *m)
+	chat.ActiveLeafID = &m.ID
+	return nil
+}
+
+func (r *memRepo) FinalizeMessage(ctx context.Context, m *models.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.index(m.ID)
+	if i < 0 {
+		return models.ErrNotFound
+	}
+	stored := &r.messages[i]
+	stored.Content, stored.Status, stored.Error, stored.Model = m.Content, m.Status, m.Error, m.Model
+	stored.Structured, stored.Suggestions = m.Structured, m.Suggestions
+	if m.ParentID != nil {
+		stored.ParentID = m.ParentID
+	}
+	m.CreatedAt = time.Now()
+	r.chats[stored.ChatID].ActiveLeafID = &stored.ID
+	return nil
+}
+
+func (r *memRepo) TransitionMessageStatus(ctx context.Context, id uuid.UUID, from, to string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.index(id)
+	if i < 0 || r.messages[i].Status != from {
+		return models.ErrNotFound
+	}
+	r.messages[i].Status, r.messages[i].Error = to, ""
+	return nil
+}
+
+func (r *memRepo) ReclaimPendingMessage(ctx context.Context, id uuid.UUID, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.index(id)
+	if i < 0 || r.messages[i].Status != models.MessageStatusPending || !r.messages[i].CreatedAt.Before(before) {
+		return models.ErrNotFound
+	}
+	r.messages[i].CreatedAt, r.messages[i].Error = time.Now(), ""
+	return nil
+}
+
+func (r *memRepo) SetActiveLeaf(ctx context.Context, chatID, messageID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.index(messageID)
+	if i < 0 || r.messages[i].ChatID != chatID {
+		return models.ErrNotFound
+	}
+	r.chats[chatID].ActiveLeafID = &r.messages[i].ID
+	return nil
+}
+
+func (r *memRepo) DeleteMessages(ctx context.Context, ids []uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = slices.DeleteFunc(r.messages, func(m models.Message) bool { return slices.Contains(ids, m.ID) })
+	for i, m := range r.messages {
+		if m.ParentID != nil && slices.Contains(ids, *m.ParentID) {
+			r.messages[i].ParentID = nil
+		}
+	}
+	return nil
+}
+
+func (r *memRepo) GetLatestSummary(ctx context.Context, chatID uuid.UUID) (*models.ChatSummary, error) {
+	return nil, models.ErrNotFound
+}
+
+func (r *memRepo) ListProducts(ctx context.Context, activeOnly bool) ([]models.Product, error) {
+	return r.products, nil
+}
+
+func (r *memRepo) ListGoals(ctx context.Context, userID uuid.UUID, status string) ([]models.Goal, error) {
+	return nil, nil
+}
+
+// addGoal stores goal with its actions, assigning IDs where missing.
+func (r *memRepo) addGoal(goal models.Goal) *models.Goal {
+	if goal.ID == uuid.Nil {
+		goal.ID = uuid.New()
+	}
+	for i := range goal.Actions {
+		goal.Actions[i].GoalID = goal.ID
+		if goal.Actions[i].Status == "" {
+			goal.Actions[i].Status = models.ActionStatusPending
+		}
+	}
+	r.goals[goal.ID] = &goal
+	return &goal
+}
+
+func (r *memRepo) GetGoal(ctx context.Context, userID, id uuid.UUID) (*models.Goal, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	goal, ok := r.goals[id]
+	if !ok || goal.UserID != userID {
+		return nil, models.ErrNotFound
+	}
+	out := *goal
+	out.Actions = slices.Clone(goal.Actions)
+	return &out, nil
+}
+
+func (r *memRepo) LockGoal(ctx context.Context, userID, id uuid.UUID) error {
+	_, err := r.GetGoal(ctx, userID, id)
+	return err
+}
+
+func (r *memRepo) action(goalID, id uuid.UUID) *models.GoalAction {
+	goal, ok := r.goals[goalID]
+	if !ok {
+		return nil
+	}
+	for i := range goal.Actions {
+		if goal.Actions[i].ID == id {
+			return &goal.Actions[i]
+		}
+	}
+	return nil
+}
+
+func (r *memRepo) UpdateGoalAction(ctx context.Context, goalID, id uuid.UUID, req *models.UpdateGoalActionRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a := r.action(goalID, id)
+	if a == nil {
+		return models.ErrNotFound
+	}
+	if req.Description != nil {
+		a.Description = *req.Description
+	}
+	if req.Amount != nil {
+		a.Amount = *req.Amount
+	}
+	switch {
+	case req.ClearDueDate:
+		a.DueDate = nil
+	case req.DueDate != nil:
+		a.DueDate = req.DueDate
+	}
+	switch {
+	case req.ClearDependsOn:
+		a.DependsOn = nil
+	case req.DependsOn != nil:
+		a.DependsOn = req.DependsOn
+	}
+	return nil
+}
+
+func (r *memRepo) CompleteGoalAction(ctx context.Context, goalID, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a := r.action(goalID, id)
+	if a == nil {
+		return models.ErrNotFound
+	}
+	if a.Status == models.ActionStatusPending {
+		a.Status = models.ActionStatusDone
+		r.goals[goalID].SavedAmount += a.Amount
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"backend/models"
+
+	"github.com/google/uuid"
+)
+
+const maxSearchQueryLen = 200
+
+var ErrInvalidQuery = errors.New("q must be between 1 and 200 characters")
+
+// Search finds the user's messages and chats matching params.Query, best
+// matches first.
+func (s *service) Search(ctx context.Context, userID uuid.UUID, params models.SearchParams) (*models.SearchPage, error) {
+	query := strings.TrimSpace(params.Query)
+	if query == "" || utf8.RuneCountInString(query) > maxSearchQueryLen {
+		return nil, ErrInvalidQuery
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = models.DefaultSearchPageSize
+	}
+	if limit > models.MaxSearchPageSize {
+		limit = models.MaxSearchPageSize
+	}
+
+	var cursor *models.SearchCursor
+	if params.Cursor != "" {
+		c, err := decodeSearchCursor(params.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		cursor = c
+	}
+
+	// Fetch one extra row to know whether another page exists.
+	hits, err := s.repo.Search(ctx, userID, query, limit+1, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.SearchPage{Hits: hits}
+	if len(hits) > limit {
+		page.Hits = hits[:limit]
+		last := page.Hits[limit-1]
+		id := last.ChatID
+		if last.MessageID != nil {
+			id = *last.MessageID
+		}
+		page.NextCursor = encodeSearchCursor(models.SearchCursor{Rank: last.Rank, ID: id})
+	}
+	return page, nil
+}
+
+func encodeSearchCursor(c models.SearchCursor) string {
+	raw := strconv.FormatFloat(float64(c.Rank), 'g', -1, 32) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeSearchCursor(s string) (*models.SearchCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	r, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	rank, err := strconv.ParseFloat(r, 32)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	hitID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &models.SearchCursor{Rank: float32(rank), ID: hitID}, nil
+}
@@ -1,178 +1,243 @@
 package services
 
 import (
+	"backend/auth"
+	"backend/config"
+	"backend/llm"
 	"backend/models"
 	"backend/repositories"
-	"bytes"
+	"backend/validation"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
+
 	"github.com/google/uuid"
-	"io"
-	"net/http"
-	"time"
 )
 
 type Service interface {
-	GetChatByID(ctx context.Context, chatID uuid.UUID) (*models.Chat, error)
-	LLMRequestAndSave(ctx context.Context, message *models.Message, fullChat *models.Chat) (*models.Message, error)
-	CreateNewChat(ctx context.Context, chatID uuid.UUID, req *models.Message) (*models.Chat, error)
-	LLMRequest(ctx context.Context, requestMessage *models.Message, fullChat *models.Chat) (*models.Message, error)
-}
+	GetChatByID(ctx context.Context, userID, chatID uuid.UUID) (*models.Chat, error)
+	GetChatTree(ctx context.Context, userID, chatID uuid.UUID) (*models.Chat, error)
+	LLMRequestAndSave(ctx context.Context, message *models.Message, fullChat *models.Chat, opts models.ReplyOptions) (*models.Message, error)
+	CreateNewChat(ctx context.Context, userID, chatID uuid.UUID, model string, req *models.Message, opts models.ReplyOptions) (*models.Chat, error)
+	ListModels() []config.ModelSpec
+	ResolveModel(name string) (string, error)
+	LLMRequest(ctx context.Context, requestMessage *models.Message, fullChat *models.Chat, opts models.ReplyOptions) (reply *models.Message, toolSteps []models.Message, err error)
+	SuggestionText(ctx context.Context, chatID, suggestionID uuid.UUID) (string, error)
+	RetryMessage(ctx context.Context, userID, chatID, messageID uuid.UUID, opts models.ReplyOptions) (*models.Message, error)
+	RegenerateMessage(ctx context.Context, userID, chatID, messageID uuid.UUID, opts models.ReplyOptions) (*models.Message, error)
+	EditMessage(ctx context.Context, userID, chatID, messageID uuid.UUID, content string, opts models.ReplyOptions) (*models.Turn, error)
+	ActivateMessage(ctx context.Context, userID, chatID, messageID uuid.UUID) (*models.Chat, error)
+	LLMStreamAndSave(ctx context.Context, message *models.Message, fullChat *models.Chat, onDelta llm.DeltaFunc) (*models.Message, error)
+	EnsureTitle(ctx context.Context, chat *models.Chat) (string, bool, error)
+	LLMStats() []llm.ModelStats
+	ListChats(ctx context.Context, userID uuid.UUID, params models.ChatListParams) (*models.ChatPage, error)
+	UpdateChat(ctx context.Context, userID, chatID uuid.UUID, req *models.UpdateChatRequest) (*models.Chat, error)
+	DeleteChat(ctx context.Context, userID, chatID uuid.UUID) error
+	Search(ctx context.Context, userID uuid.UUID, params models.SearchParams) (*models.SearchPage, error)
+	ExportChat(ctx context.Context, userID, chatID uuid.UUID, format string) (*models.ExportFile, error)
+	ImportChat(ctx context.Context, userID uuid.UUID, export *models.ChatExport) (*models.Chat, error)
 
-func NewService(repo repositories.Repository) Service {
-	return &service{
-		repo: repo,
-	}
-}
+	Register(ctx context.Context, req *models.RegisterRequest) (*models.AuthResponse, error)
+	Login(ctx context.Context, req *models.LoginRequest) (*models.AuthResponse, error)
+	GetUser(ctx context.Context, userID uuid.UUID) (*models.User, error)
+	StartGuestSession(ctx context.Context) (*models.AuthResponse, error)
+	ClaimGuestChats(ctx context.Context, userID uuid.UUID, guestToken string) (int64, error)
+	DiscardGuestSession(ctx context.Context, guestID uuid.UUID) error
+	CleanupExpiredGuests(ctx context.Context) (int64, error)
 
-type service struct {
-	repo repositories.Repository
-}
-
-func (s *service) LLMRequest(ctx context.Context, requestMessage *models.Message, fullChat *models.Chat) (*models.Message, error) {
-	llmURL := "https://openai-hub.neuraldeep.tech/v1/chat/completions"
-	api_key := "sk-roG3OusRr0TLCHAADks6lw"
+	ListProducts(ctx context.Context, activeOnly bool) ([]models.Product, error)
+	GetProduct(ctx context.Context, id uuid.UUID) (*models.Product, error)
+	CreateProduct(ctx context.Context, req *models.ProductRequest) (*models.Product, error)
+	UpdateProduct(ctx context.Context, id uuid.UUID, req *models.ProductRequest) (*models.Product, error)
+	DeleteProduct(ctx context.Context, id uuid.UUID) error
 
-	req := models.LLMAPIRequest{
-		Model: models.LLMModel,
-	}
-	messages := []models.MessagesAPI{}
-	for _, m := range fullChat.Messages {
-		mapi := models.MessagesAPI{m.Role, m.Content}
-		messages = append(messages, mapi)
-	}
+	CheckEligibility(ctx context.Context, req *models.EligibilityRequest) (*models.EligibilityResult, error)
+	CalculateCushion(ctx context.Context, req *models.CushionRequest) (*models.CushionPlan, error)
+	CalculateSchedule(ctx context.Context, req *models.ScheduleRequest) (*models.Schedule, error)
 
-	messages = append(messages, models.MessagesAPI{Role: requestMessage.Role, Content: requestMessage.Content})
-	req.Messages = messages
+	ListGoals(ctx context.Context, userID uuid.UUID, status string) ([]models.Goal, error)
+	GetGoal(ctx context.Context, userID, id uuid.UUID) (*models.Goal, error)
+	CreateGoal(ctx context.Context, userID uuid.UUID, req *models.CreateGoalRequest) (*models.Goal, error)
+	UpdateGoal(ctx context.Context, userID, id uuid.UUID, req *models.UpdateGoalRequest) (*models.Goal, error)
+	DeleteGoal(ctx context.Context, userID, id uuid.UUID) error
+	CreateGoalAction(ctx context.Context, userID, goalID uuid.UUID, req *models.CreateGoalActionRequest) (*models.Goal, error)
+	UpdateGoalAction(ctx context.Context, userID, goalID, actionID uuid.UUID, req *models.UpdateGoalActionRequest) (*models.Goal, error)
+	CompleteGoalAction(ctx context.Context, userID, goalID, actionID uuid.UUID) (*models.Goal, error)
+	DeleteGoalAction(ctx context.Context, userID, goalID, actionID uuid.UUID) error
+}
 
-	jsonData, err := json.Marshal(req)
-	if err != nil {
-		return nil, err
+func NewService(cfg *config.Config, repo repositories.Repository, provider llm.Provider, tokens *auth.Manager) Service {
+	s := &service{
+		cfg:      cfg,
+		repo:     repo,
+		llm:      provider,
+		tokens:   tokens,
+		counter:  llm.NewTokenCounter(),
+		validate: validation.New(),
 	}
-	fmt.Println(string(jsonData))
+	s.tools = s.newToolset()
+	return s
+}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", llmURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		fmt.Printf("Error creating request: %v\n", err)
-		return nil, err
-	}
+type service struct {
+	cfg     *config.Config
+	repo    repositories.Repository
+	llm     llm.Provider
+	tokens  *auth.Manager
+	counter *llm.TokenCounter
 
-	// Set headers
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", api_key)) // You should make this configurable
+	validate *validation.CustomValidator
+	tools    *toolset
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
+	summarizing sync.Map // chat IDs with a summary being generated
+	prompt      promptCache
+}
 
-	// Make the request
-	resp, err := client.Do(httpReq)
+// LLMRequest answers requestMessage, letting the model call backend tools on
+// the way. The tool calls and their results are returned as toolSteps for the
+// caller to persist before the reply.
+func (s *service) LLMRequest(ctx context.Context, requestMessage *models.Message, fullChat *models.Chat, opts models.ReplyOptions) (*models.Message, []models.Message, error) {
+	req, err := s.buildLLMRequest(ctx, requestMessage, fullChat)
 	if err != nil {
-		fmt.Printf("Error making request: %v\n", err)
-		return nil, err
+		return nil, nil, err
+	}
+	if opts.Structured {
+		s.requestStructured(req)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	steps, resp, err := s.completeWithTools(ctx, req, fullChat, s.llm.ChatCompletion)
 	if err != nil {
-		fmt.Printf("Error reading response: %v\n", err)
-		return nil, err
+		return nil, nil, err
 	}
 
-	// Check if request was successful
-	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("LLM API returned error status %d: %s\n", resp.StatusCode, string(body))
-		return nil, err
+	reply := &models.Message{
+		Role:    resp.Message.Role,
+		Content: resp.Message.Content,
+		Model:   resp.Model,
 	}
-	// Parse the response
-	var llmResponse struct {
-		Choices []struct {
-			Message models.Message `json:"message"`
-		} `json:"choices"`
+	if opts.Structured {
+		s.structureReply(ctx, req, reply)
 	}
+	attachSuggestions(reply)
+	return reply, steps, nil
+}
 
-	if err := json.Unmarshal(body, &llmResponse); err != nil {
-		fmt.Printf("Error parsing response: %v\n", err)
+// LLMRequestAndSave answers requestMessage. The user message is stored with a
+// pending reply first, which is completed with the answer or marked failed.
+func (s *service) LLMRequestAndSave(ctx context.Context, requestMessage *models.Message, fullChat *models.Chat, opts models.ReplyOptions) (*models.Message, error) {
+	continueBranch(requestMessage, fullChat)
+	pending, err := s.startTurn(ctx, requestMessage)
+	if err != nil {
 		return nil, err
 	}
-
-	if len(llmResponse.Choices) == 0 {
-		return nil, errors.New("error llm response")
-	}
-
-	responseMessage := &llmResponse.Choices[0].Message
-	return responseMessage, nil
+	return s.runTurn(ctx, pending, requestMessage, fullChat, opts)
 }
 
-func (s *service) LLMRequestAndSave(ctx context.Context, requestMessage *models.Message, fullChat *models.Chat) (*models.Message, error) {
-	if err := s.repo.SaveMessage(ctx, requestMessage); err != nil {
-		return nil, err
-	}
-	responseMessage, err := s.LLMRequest(ctx, requestMessage, fullChat)
+// CreateNewChat starts a chat on model, or on the default model if it is
+// empty, and answers its first message.
+func (s *service) CreateNewChat(ctx context.Context, userID, chatID uuid.UUID, model string, req *models.Message, opts models.ReplyOptions) (*models.Chat, error) {
+	model, err := s.ResolveModel(model)
 	if err != nil {
 		return nil, err
 	}
-	responseMessage.ChatID = fullChat.ID
-	if err := s.repo.SaveMessage(ctx, responseMessage); err != nil {
+	prompt, err := s.systemPrompt(ctx)
+	if err != nil {
 		return nil, err
 	}
-	fmt.Println("resp", responseMessage)
-	return responseMessage, nil
-}
-
-func (s *service) CreateNewChat(ctx context.Context, chatID uuid.UUID, req *models.Message) (*models.Chat, error) {
-	systemMessage := &models.Message{ChatID: chatID, Role: "system", Content: models.BasePrompt}
-	chat := &models.Chat{ID: chatID, Messages: []models.Message{*systemMessage}}
-	response, err := s.LLMRequest(ctx, req, chat)
+	systemMessage := &models.Message{ChatID: chatID, Role: "system", Content: prompt}
+	chat := &models.Chat{ID: chatID, UserID: userID, Model: model, Messages: []models.Message{*systemMessage}}
+	response, steps, err := s.LLMRequest(ctx, req, chat, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	allMessages := chat.Messages
 	allMessages = append(allMessages, *req)
+	allMessages = append(allMessages, steps...)
 	allMessages = append(allMessages, *response)
 
 	chat.Messages = allMessages
 
-	createNamePrompt := "Generate a short and concise title for a chat based on the user prompt. The title should be no more than 5 words"
-	chatNameMessage, err := s.LLMRequest(ctx, &models.Message{ChatID: chatID, Role: "user", Content: createNamePrompt}, chat)
+	chatName, err := s.generateTitle(ctx, chat)
 	if err != nil {
 		return nil, errors.New("llmreq for chat name failed: " + err.Error())
 	}
 
-	err = s.repo.CreateNewChat(ctx, chatID, chatNameMessage.Content)
+	// The chat only appears together with its opening turn.
+	response.ChatID = chatID
+	err = s.repo.WithTx(ctx, func(repo repositories.Repository) error {
+		if err := repo.CreateNewChat(ctx, userID, chatID, chatName, model); err != nil {
+			return errors.New("create new chat in repo failed: " + err.Error())
+		}
+		if err := repo.SaveMessage(ctx, systemMessage); err != nil {
+			return errors.New("save system message failed: " + err.Error())
+		}
+		req.ParentID = &systemMessage.ID
+		return saveTurn(ctx, repo, req, steps, response)
+	})
 	if err != nil {
-		return nil, errors.New("create new chat in repo failed: " + err.Error())
+		return nil, err
 	}
+	chat.Title = chatName
+	chat.Messages = append(append([]models.Message{*req}, steps...), *response)
+	return chat, nil
+}
 
-	if err := s.repo.SaveMessage(ctx, systemMessage); err != nil {
-		return nil, errors.New("save system message failed: " + err.Error())
+const createNamePrompt = "Generate a short and concise title for a chat based on the user prompt. The title should be no more than 5 words"
+
+// generateTitle asks for a title without tools: there is nothing to compute.
+func (s *service) generateTitle(ctx context.Context, chat *models.Chat) (string, error) {
+	req, err := s.buildLLMRequest(ctx, &models.Message{ChatID: chat.ID, Role: "user", Content: createNamePrompt}, chat)
+	if err != nil {
+		return "", err
 	}
-	if err := s.repo.SaveMessage(ctx, req); err != nil {
-		return nil, errors.New("save req message failed: " + err.Error())
+	resp, err := s.llm.ChatCompletion(ctx, req)
+	if err != nil {
+		return "", err
 	}
-	response.ChatID = chatID
-	chat.Title = chatNameMessage.Content
-	if err := s.repo.SaveMessage(ctx, response); err != nil {
-		return nil, errors.New("save response message failed: " + err.Error())
+	return resp.Message.Content, nil
+}
+
+// EnsureTitle generates and stores a title for chats that do not have one
+// yet. It returns the current title and whether it was just generated.
+func (s *service) EnsureTitle(ctx context.Context, chat *models.Chat) (string, bool, error) {
+	if chat.Title != "" {
+		return chat.Title, false, nil
 	}
-	for i, m := range chat.Messages {
-		if m.Role == "system" {
-			chat.Messages = append(chat.Messages[:i], chat.Messages[i+1:]...)
-			break
-		}
+	title, err := s.generateTitle(ctx, chat)
+	if err != nil {
+		return "", false, err
 	}
-	return chat, nil
+	if err := s.repo.UpdateChatTitle(ctx, chat.ID, title); err != nil {
+		return "", false, err
+	}
+	chat.Title = title
+	return title, true, nil
 }
 
-func (s *service) GetChatByID(ctx context.Context, chatID uuid.UUID) (*models.Chat, error) {
-	ch, err := s.repo.GetChatAndMessages(ctx, chatID)
+// GetChatByID returns a chat with its active branch.
+func (s *service) GetChatByID(ctx context.Context, userID, chatID uuid.UUID) (*models.Chat, error) {
+	return s.getChat(ctx, userID, chatID, false)
+}
+
+// GetChatTree returns a chat with the messages of all its branches.
+func (s *service) GetChatTree(ctx context.Context, userID, chatID uuid.UUID) (*models.Chat, error) {
+	return s.getChat(ctx, userID, chatID, true)
+}
+
+func (s *service) getChat(ctx context.Context, userID, chatID uuid.UUID, tree bool) (*models.Chat, error) {
+	ch, err := s.repo.GetChatAndMessages(ctx, userID, chatID, tree)
 	if err != nil {
 		return nil, err
 	}
+
+	summary, err := s.repo.GetLatestSummary(ctx, chatID)
+	if err != nil && !errors.Is(err, models.ErrNotFound) {
+		return nil, err
+	}
+	ch.Summary = summary
+	ch.UserID = userID
 	for i, m := range ch.Messages {
 		if m.Role == "system" {
 			ch.Messages = append(ch.Messages[:i], ch.Messages[i+1:]...)
@@ -182,3 +247,31 @@ func (s *service) GetChatByID(ctx context.Context, chatID uuid.UUID) (*models.Ch
 
 	return ch, nil
 }
+
+// LLMStats returns the per-model call counters of the provider chain, or nil
+// if the provider does not keep any.
+func (s *service) LLMStats() []llm.ModelStats {
+	if p, ok := s.llm.(interface{ Stats() []llm.ModelStats }); ok {
+		return p.Stats()
+	}
+	return nil
+}
+
+var ErrUnknownModel = errors.New("unknown model")
+
+// ListModels returns the model registry.
+func (s *service) ListModels() []config.ModelSpec {
+	return s.cfg.LLM.Models
+}
+
+// ResolveModel checks name against the registry; an empty name means the
+// default model.
+func (s *service) ResolveModel(name string) (string, error) {
+	if name == "" {
+		return s.cfg.LLM.DefaultModel, nil
+	}
+	if _, ok := s.cfg.LLM.Model(name); !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownModel, name)
+	}
+	return name, nil
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/config"
+	"backend/llm"
+	"backend/models"
+
+	"github.com/google/uuid"
+)
+
+const testModel = "gpt-4o-mini"
+
+func newTestService(t *testing.T) (*service, *memRepo, *llm.Fake) {
+	t.Helper()
+	cfg := &config.Config{LLM: config.LLMConfig{
+		DefaultModel:      testModel,
+		Models:            []config.ModelSpec{{Name: testModel, ContextWindow: 128000, Tools: true, JSONMode: true}},
+		ContextBudget:     8000,
+		MaxToolIterations: 3,
+	}}
+	repo := newMemRepo()
+	fake := llm.NewFake()
+	return NewService(cfg, repo, fake, nil).(*service), repo, fake
+}
+
+func TestLLMRequestPrompt(t *testing.T) {
+	s, repo, fake := newTestService(t)
+	chat := repo.addChat(uuid.New(), testModel,
+		models.Message{Role: "system", Content: "stored prompt"},
+		models.Message{Role: "user", Content: "Привет"},
+		models.Message{Role: "assistant", ToolCalls: []models.ToolCall{{ID: "c1", Name: "get_goals"}}},
+		models.Message{Role: "tool", ToolCallID: "c1", Content: "[]"},
+		models.Message{Role: "assistant", Content: "Здравствуйте!"},
+		models.Message{Role: "assistant", Status: models.MessageStatusFailed},
+	)
+	full, err := s.GetChatByID(context.Background(), chat.UserID, chat.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.Push("Ответ")
+
+	request := &models.Message{ChatID: chat.ID, Role: "user", Content: "Сколько копить?"}
+	reply, steps, err := s.LLMRequest(context.Background(), request, full, models.ReplyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Content != "Ответ" || reply.Role != "assistant" || len(steps) != 0 {
+		t.Errorf("reply = %+v, steps = %d; want the queued answer and no steps", reply, len(steps))
+	}
+
+	sent := fake.Requests()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(sent))
+	}
+	var got []string
+	for _, m := range sent[0].Messages {
+		got = append(got, m.Role+": "+m.Content)
+	}
+	if !strings.HasPrefix(got[0], "system: ") || strings.Contains(got[0], "stored prompt") {
+		t.Errorf("first message = %.60q, want the live system prompt", got[0])
+	}
+	// Tool steps and the failed reply stay out of the prompt.
+	want := []string{"user: Привет", "assistant: Здравствуйте!", "user: Сколько копить?"}
+	if strings.Join(got[1:], "\n") != strings.Join(want, "\n") {
+		t.Errorf("history = %q, want %q", got[1:], want)
+	}
+	if sent[0].Model != testModel || len(sent[0].Tools) == 0 {
+		t.Errorf("request model %q with %d tools, want %s with the toolset", sent[0].Model, len(sent[0].Tools), testModel)
+	}
+}
+
+func TestLLMRequestAndSave(t *testing.T) {
+	s, repo, fake := newTestService(t)
+	chat := repo.addChat(uuid.New(), testModel, models.Message{Role: "system", Content: "prompt"})
+	full, err := s.GetChatByID(context.Background(), chat.UserID, chat.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.Push("Ответ")
+
+	request := &models.Message{ChatID: chat.ID, Role: "user", Content: "Вопрос"}
+	reply, err := s.LLMRequestAndSave(context.Background(), request, full, models.ReplyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved := repo.message(reply.ID)
+	if saved.Status != models.MessageStatusCompleted || saved.Content != "Ответ" {
+		t.Errorf("stored reply = %+v, want the completed answer", saved)
+	}
+	if saved.ParentID == nil || *saved.ParentID != request.ID {
+		t.Errorf("reply parent = %v, want the request %s", saved.ParentID, request.ID)
+	}
+	if leaf := repo.chats[chat.ID].ActiveLeafID; leaf == nil || *leaf != reply.ID {
+		t.Errorf("active leaf = %v, want the reply %s", leaf, reply.ID)
+	}
+}
+
+func TestLLMRequestAndSaveFailure(t *testing.T) {
+	s, repo, fake := newTestService(t)
+	chat := repo.addChat(uuid.New(), testModel, models.Message{Role: "system", Content: "prompt"})
+	full, err := s.GetChatByID(context.Background(), chat.UserID, chat.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.PushError(&llm.ProviderError{Kind: llm.KindOverloaded, Message: "down"})
+
+	request := &models.Message{ChatID: chat.ID, Role: "user", Content: "Вопрос"}
+	if _, err := s.LLMRequestAndSave(context.Background(), request, full, models.ReplyOptions{}); err == nil {
+		t.Fatal("LLMRequestAndSave succeeded, want the provider error")
+	}
+
+	branch, _ := repo.ListBranch(context.Background(), chat.ID)
+	last := branch[len(branch)-1]
+	if last.Role != "assistant" || last.Status != models.MessageStatusFailed || last.Error != "llm_overloaded" {
+		t.Errorf("last message = %+v, want a failed reply with code llm_overloaded", last)
+	}
+}
+
+func TestToolCallsAreSaved(t *testing.T) {
+	s, repo, fake := newTestService(t)
+	chat := repo.addChat(uuid.New(), testModel, models.Message{Role: "system", Content: "prompt"})
+	full, err := s.GetChatByID(context.Background(), chat.UserID, chat.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.PushToolCalls(llm.ToolCall{ID: "call-1", Type: "function", Function: llm.ToolCallFunction{
+		Name: "compute_savings_cushion", Arguments: `{"monthly_expenses": 300000}`,
+	}})
+	fake.Push("Подушка: 1 800 000 ₸")
+
+	request := &models.Message{ChatID: chat.ID, Role: "user", Content: "Посчитай подушку"}
+	reply, err := s.LLMRequestAndSave(context.Background(), request, full, models.ReplyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	branch, _ := repo.ListBranch(context.Background(), chat.ID)
+	if len(branch) != 5 {
+		t.Fatalf("branch has %d messages, want system, request, tool call, tool result, reply", len(branch))
+	}
+	call, result := branch[2], branch[3]
+	if len(call.ToolCalls) != 1 || call.ToolCalls[0].Name != "compute_savings_cushion" {
+		t.Errorf("tool call step = %+v", call)
+	}
+	if result.Role != "tool" || result.ToolCallID != "call-1" || !strings.Contains(result.Content, `"target":1800000`) {
+		t.Errorf("tool result step = %+v", result)
+	}
+	if branch[4].ID != reply.ID || *branch[4].ParentID != result.ID {
+		t.Errorf("reply is not chained below the tool result")
+	}
+
+	// The second round carries the call and its result back to the model.
+	sent := fake.Requests()
+	if len(sent) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(sent))
+	}
+	msgs := sent[1].Messages
+	if last := msgs[len(msgs)-1]; last.Role != "tool" || last.ToolCallID != "call-1" {
+		t.Errorf("second request ends with %+v, want the tool result", last)
+	}
+}
+
+func TestToolLoopIsBounded(t *testing.T) {
+	s, repo, fake := newTestService(t)
+	chat := repo.addChat(uuid.New(), testModel, models.Message{Role: "system", Content: "prompt"})
+	full, err := s.GetChatByID(context.Background(), chat.UserID, chat.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.Reply = func(req *llm.ChatRequest) (*llm.ChatResponse, error) {
+		return &llm.ChatResponse{Message: llm.Message{Role: "assistant", ToolCalls: []llm.ToolCall{{
+			ID: "again", Type: "function", Function: llm.ToolCallFunction{Name: "get_goals", Arguments: `{}`},
+		}}}}, nil
+	}
+
+	request := &models.Message{ChatID: chat.ID, Role: "user", Content: "Мои цели"}
+	_, _, err = s.LLMRequest(context.Background(), request, full, models.ReplyOptions{})
+	if !errors.Is(err, ErrToolLoop) {
+		t.Fatalf("err = %v, want ErrToolLoop", err)
+	}
+	sent := fake.Requests()
+	if len(sent) != 3 || sent[2].ToolChoice != llm.ToolChoiceNone {
+		t.Errorf("sent %d requests, want 3 with tools disabled on the last", len(sent))
+	}
+}
+
+func TestRetryMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		age    time.Duration
+		want   error
+	}{
+		{"failed", models.MessageStatusFailed, time.Minute, nil},
+		{"abandoned", models.MessageStatusPending, stalePendingAfter + time.Minute, nil},
+		{"in progress", models.MessageStatusPending, time.Minute, ErrNotRetryable},
+		{"completed", models.MessageStatusCompleted, time.Hour, ErrNotRetryable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, fake := newTestService(t)
+			chat := repo.addChat(uuid.New(), testModel,
+				models.Message{Role: "system", Content: "prompt"},
+				models.Message{Role: "user", Content: "Вопрос"},
+				models.Message{Role: "assistant", Status: tt.status, CreatedAt: time.Now().Add(-tt.age)},
+			)
+			fake.Push("Ответ")
+
+			reply, err := s.RetryMessage(context.Background(), chat.UserID, chat.ID, *chat.ActiveLeafID, models.ReplyOptions{})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if err != nil {
+				return
+			}
+			saved := repo.message(reply.ID)
+			if reply.ID != *chat.ActiveLeafID || saved.Status != models.MessageStatusCompleted || saved.Content != "Ответ" {
+				t.Errorf("retried reply = %+v, want the same message completed", saved)
+			}
+		})
+	}
+}
+
+func TestRetryDropsStaleToolSteps(t *testing.T) {
+	s, repo, fake := newTestService(t)
+	chat := repo.addChat(uuid.New(), testModel,
+		models.Message{Role: "system", Content: "prompt"},
+		models.Message{Role: "user", Content: "Вопрос"},
+		models.Message{Role: "assistant", ToolCalls: []models.ToolCall{{ID: "c1", Name: "get_goals"}}},
+		models.Message{Role: "tool", ToolCallID: "c1", Content: "[]"},
+		models.Message{Role: "assistant", Status: models.MessageStatusFailed},
+	)
+	fake.Push("Ответ")
+
+	if _, err := s.RetryMessage(context.Background(), chat.UserID, chat.ID, *chat.ActiveLeafID, models.ReplyOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// Deleting the steps nulls the reply's parent, as in Postgres; the retry
+	// must chain it below the question again.
+	want := []string{"prompt", "Вопрос", "Ответ"}
+	if got := contents(repo.branch(chat.ID)); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("active branch = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"backend/llm"
+	"backend/models"
+)
+
+// LLMStreamAndSave saves the user message with a pending reply, streams the
+// assistant reply through onDelta and persists whatever was assembled,
+// preceded by any tool calls the model made. When the client goes away or the
+// provider breaks mid-stream the partial reply is still saved, flagged as
+// cancelled or failed respectively; if nothing arrived at all, the pending
+// reply is settled empty with the error code.
+func (s *service) LLMStreamAndSave(ctx context.Context, requestMessage *models.Message, fullChat *models.Chat, onDelta llm.DeltaFunc) (*models.Message, error) {
+	// Saved up front so live listeners can show it before the reply.
+	continueBranch(requestMessage, fullChat)
+	pending, err := s.startTurn(ctx, requestMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := s.buildLLMRequest(ctx, requestMessage, fullChat)
+	if err != nil {
+		s.failTurn(ctx, pending, err)
+		return nil, err
+	}
+
+	steps, resp, streamErr := s.completeWithTools(ctx, req, fullChat,
+		func(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+			return s.llm.ChatCompletionStream(ctx, req, onDelta)
+		})
+	if resp == nil || resp.Message.Content == "" {
+		if streamErr == nil {
+			streamErr = errors.New("llm returned an empty stream")
+		}
+		s.failTurn(ctx, pending, streamErr)
+		return nil, streamErr
+	}
+
+	responseMessage := &models.Message{
+		Role:    resp.Message.Role,
+		Content: resp.Message.Content,
+		Status:  models.MessageStatusCompleted,
+		Model:   resp.Model,
+	}
+	if streamErr != nil {
+		responseMessage.Status = models.MessageStatusFailed
+		if ctx.Err() != nil {
+			responseMessage.Status = models.MessageStatusCancelled
+		}
+		responseMessage.Error = failureCode(streamErr)
+	} else {
+		attachSuggestions(responseMessage)
+	}
+
+	// The request context is likely cancelled by now if the client left, but
+	// the partial reply still has to reach the database.
+	if err := s.finishTurn(context.WithoutCancel(ctx), pending, steps, responseMessage); err != nil {
+		return nil, err
+	}
+
+	if streamErr == nil {
+		s.maybeSummarize(fullChat.ID)
+	}
+	return responseMessage, streamErr
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"backend/llm"
+	"backend/models"
+)
+
+// structuredSchema mirrors models.StructuredReply. Strict mode does not
+// accept item counts, so those are only enforced by validation.
+const structuredSchema = `{
+	"type": "object",
+	"properties": {
+		"reflection": {"type": "string"},
+		"steps": {"type": "array", "items": {"type": "string"}},
+		"recommendations": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"product": {"type": "string"},
+					"why": {"type": "string"}
+				},
+				"required": ["product", "why"],
+				"additionalProperties": false
+			}
+		},
+		"choices": {"type": "array", "items": {"type": "string"}},
+		"question": {"type": "string"}
+	},
+	"required": ["reflection", "steps", "recommendations", "choices", "question"],
+	"additionalProperties": false
+}`
+
+const structuredInstruction = `Ответ верни JSON-объектом:
+reflection — краткое отражение запроса клиента;
+steps — 1–3 простых микро-шага;
+recommendations — 0–2 подходящих продукта Zaman, у каждого product (название) и why (почему подходит), пусто, если данных пока мало;
+choices — 2–3 коротких варианта продолжения (до 80 символов), клиент нажмёт один из них как кнопку;
+question — мягкий вопрос-приглашение.`
+
+const structuredRetryPrompt = "Ответ не прошёл проверку: %v. Верни исправленный ответ тем же JSON-объектом, соблюдая количество элементов."
+
+// requestStructured switches req to structured output. Models without JSON
+// mode only get the instruction; their reply is validated all the same.
+func (s *service) requestStructured(req *llm.ChatRequest) {
+	if spec, ok := s.cfg.LLM.Model(req.Model); ok && spec.JSONMode {
+		req.ResponseFormat = llm.NewJSONSchemaFormat("coach_reply", structuredSchema)
+	}
+	// Right after the system prompt, ahead of the history.
+	req.Messages = slices.Insert(req.Messages, 1, llm.Message{Role: "system", Content: structuredInstruction})
+}
+
+// structureReply parses reply.Content into reply.Structured. On a schema
+// violation the model is asked once more with the validation error, provided
+// the longer request still fits the context budget. A reply that cannot be
+// structured is requested again as prose without the schema, so clients are
+// not shown raw JSON; only if that fails as well is the first reply kept.
+func (s *service) structureReply(ctx context.Context, req *llm.ChatRequest, reply *models.Message) {
+	parsed, err := s.parseStructured(reply.Content)
+	if err == nil {
+		reply.Structured = parsed
+		return
+	}
+
+	retry := *req
+	retry.Messages = append(slices.Clip(req.Messages),
+		llm.Message{Role: "assistant", Content: reply.Content},
+		llm.Message{Role: "user", Content: fmt.Sprintf(structuredRetryPrompt, err)},
+	)
+	answerInText(&retry)
+	retryErr := llm.ErrContextTooLong
+	if s.counter.CountMessages(retry.Model, retry.Messages) <= s.cfg.LLM.ContextBudgetFor(retry.Model) {
+		var resp *llm.ChatResponse
+		if resp, retryErr = s.llm.ChatCompletion(ctx, &retry); retryErr == nil {
+			if parsed, retryErr = s.parseStructured(resp.Message.Content); retryErr == nil {
+				reply.Content, reply.Model, reply.Structured = resp.Message.Content, resp.Model, parsed
+				return
+			}
+		}
+	}
+	log.Printf("Structured reply rejected (%v), retry failed: %v", err, retryErr)
+
+	resp, err := s.llm.ChatCompletion(ctx, proseRequest(req))
+	if err == nil && resp.Message.Content == "" {
+		err = errors.New("empty reply")
+	}
+	if err != nil {
+		log.Printf("Plain-text fallback of a structured reply failed: %v", err)
+		return
+	}
+	reply.Content, reply.Model = resp.Message.Content, resp.Model
+}
+
+// proseRequest is req as it was before requestStructured.
+func proseRequest(req *llm.ChatRequest) *llm.ChatRequest {
+	prose := *req
+	prose.ResponseFormat = nil
+	answerInText(&prose)
+	prose.Messages = slices.DeleteFunc(slices.Clone(req.Messages), func(m llm.Message) bool {
+		return m.Role == "system" && m.Content == structuredInstruction
+	})
+	return &prose
+}
+
+// answerInText keeps the model from calling tools again; providers reject a
+// tool choice without tools.
+func answerInText(req *llm.ChatRequest) {
+	if len(req.Tools) > 0 {
+		req.ToolChoice = llm.ToolChoiceNone
+	}
+}
+
+func (s *service) parseStructured(raw string) (*models.StructuredReply, error) {
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.DisallowUnknownFields()
+
+	var reply models.StructuredReply
+	if err := dec.Decode(&reply); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := s.validate.Validate(&reply); err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
+// structuredText renders a structured reply as plain text for places that
+// need prose: chat history sent back to the model and summaries.
+func structuredText(r *models.StructuredReply) string {
+	var b strings.Builder
+	b.WriteString(r.Reflection)
+	b.WriteString("\n")
+	for i, step := range r.Steps {
+		fmt.Fprintf(&b, "\n%d. %s", i+1, step)
+	}
+	for _, rec := range r.Recommendations {
+		fmt.Fprintf(&b, "\n— %s: %s", rec.Product, rec.Why)
+	}
+	b.WriteString("\n\n")
+	b.WriteString(r.Question)
+	for _, choice := range r.Choices {
+		fmt.Fprintf(&b, "\n• %s", choice)
+	}
+	return b.String()
+}
+
+// messageText is the content of m as prose.
+func messageText(m *models.Message) string {
+	if m.Structured != nil {
+		return structuredText(m.Structured)
+	}
+	return m.Content
+}
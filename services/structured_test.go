@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"backend/llm"
+	"backend/models"
+)
+
+const validStructured = `{"reflection": "Хотите подушку", "steps": ["Откройте Копилку"], "recommendations": [],
+	"choices": ["Открыть Копилку", "Посчитать график"], "question": "С чего начнём?"}`
+
+// structuredRequest is what LLMRequest sends in structured mode.
+func structuredRequest(s *service) *llm.ChatRequest {
+	req := s.newChatRequest(testModel, []llm.Message{
+		{Role: "system", Content: "prompt"},
+		{Role: "user", Content: "Хочу подушку"},
+	})
+	s.requestStructured(req)
+	return req
+}
+
+// replies makes the fake answer with each content in turn, as model.
+func replies(fake *llm.Fake, model string, contents ...string) {
+	fake.Reply = func(req *llm.ChatRequest) (*llm.ChatResponse, error) {
+		content := contents[0]
+		contents = contents[1:]
+		return &llm.ChatResponse{Model: model, Message: llm.Message{Role: "assistant", Content: content}}, nil
+	}
+}
+
+func TestStructureReplyRetry(t *testing.T) {
+	s, _, fake := newTestService(t)
+	replies(fake, "fallback-model", validStructured)
+
+	reply := &models.Message{Content: `{"reflection": "x"}`, Model: testModel}
+	s.structureReply(context.Background(), structuredRequest(s), reply)
+
+	if reply.Structured == nil || reply.Content != validStructured {
+		t.Fatalf("reply = %+v, want the retried structured answer", reply)
+	}
+	if reply.Model != "fallback-model" {
+		t.Errorf("model = %q, want the model that answered the retry", reply.Model)
+	}
+	sent := fake.Requests()
+	if len(sent) != 1 || sent[0].Messages[len(sent[0].Messages)-2].Content != `{"reflection": "x"}` {
+		t.Errorf("retry does not carry the rejected answer back")
+	}
+}
+
+func TestStructureReplyFallsBackToProse(t *testing.T) {
+	s, _, fake := newTestService(t)
+	replies(fake, "fallback-model", `{"still": "wrong"}`, "Начните с Копилки.")
+
+	reply := &models.Message{Content: "not json", Model: testModel}
+	s.structureReply(context.Background(), structuredRequest(s), reply)
+
+	if reply.Structured != nil || reply.Content != "Начните с Копилки." || reply.Model != "fallback-model" {
+		t.Fatalf("reply = %+v, want the prose answer", reply)
+	}
+	sent := fake.Requests()
+	if len(sent) != 2 {
+		t.Fatalf("sent %d requests, want the retry and the prose request", len(sent))
+	}
+	prose := sent[1]
+	if prose.ResponseFormat != nil || slices.ContainsFunc(prose.Messages, func(m llm.Message) bool {
+		return m.Content == structuredInstruction
+	}) {
+		t.Errorf("prose request still asks for JSON")
+	}
+}
+
+func TestStructureReplyRetryOverBudget(t *testing.T) {
+	s, _, fake := newTestService(t)
+	req := structuredRequest(s)
+	s.cfg.LLM.ContextBudgets = map[string]int{testModel: s.counter.CountMessages(testModel, req.Messages) + 10}
+	replies(fake, testModel, "Начните с Копилки.")
+
+	long, _ := json.Marshal(map[string]string{"reflection": "слишком длинный ответ без нужных полей, " +
+		"который вместе с просьбой исправиться не влезет в бюджет"})
+	reply := &models.Message{Content: string(long)}
+	s.structureReply(context.Background(), req, reply)
+
+	if sent := fake.Requests(); len(sent) != 1 || sent[0].ResponseFormat != nil {
+		t.Errorf("sent %d requests, want only the prose one", len(sent))
+	}
+	if reply.Content != "Начните с Копилки." {
+		t.Errorf("reply = %q, want the prose answer", reply.Content)
+	}
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"backend/models"
+
+	"github.com/google/uuid"
+)
+
+var ErrUnknownSuggestion = errors.New("suggestion not offered by the last reply of this chat")
+
+const (
+	minSuggestions   = 2
+	maxSuggestions   = 3
+	maxSuggestionLen = 80 // runes; longer lines are prose, not buttons
+
+	// How many non-empty lines from the end are searched for options.
+	suggestionTailLines = 10
+)
+
+// SuggestionText resolves a tapped quick reply to the text it stands for.
+// Only the options of the reply ending the active branch can be tapped: older
+// ones answer questions the conversation has moved past.
+func (s *service) SuggestionText(ctx context.Context, chatID, suggestionID uuid.UUID) (string, error) {
+	text, err := s.repo.FindSuggestion(ctx, chatID, suggestionID)
+	if errors.Is(err, models.ErrNotFound) {
+		return "", ErrUnknownSuggestion
+	}
+	return text, err
+}
+
+// attachSuggestions fills reply.Suggestions from its structured choices or,
+// for free text, from the closing options the prompt asks the coach to end
+// with.
+func attachSuggestions(reply *models.Message) {
+	var texts []string
+	if reply.Structured != nil {
+		texts = reply.Structured.Choices
+	} else {
+		texts = parseChoices(reply.Content)
+	}
+
+	reply.Suggestions = nil
+	for _, text := range texts {
+		reply.Suggestions = append(reply.Suggestions, models.Suggestion{ID: uuid.New(), Text: text})
+	}
+}
+
+// parseChoices looks for the closing options the prompt asks for: a final
+// line of options separated by " / ", or the last run of 2–3 short bulleted,
+// numbered or quoted lines near the end of content. When text follows the run,
+// typically the closing question, every line of it must be quoted as in the
+// prompt; an unquoted list followed by a question is a list of steps the
+// question asks about, not options.
+func parseChoices(content string) []string {
+	var (
+		choices  []string
+		seen     int
+		trailing bool // non-option lines come after the run
+		quoted   = true
+	)
+	lines := strings.Split(content, "\n")
+	for i := len(lines) - 1; i >= 0 && seen < suggestionTailLines; i-- {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+		seen++
+
+		if choice, q, ok := choiceLine(line); ok {
+			choices = append(choices, choice)
+			quoted = quoted && q
+			continue
+		}
+		if len(choices) > 0 {
+			break
+		}
+		if parts := slashChoices(line); parts != nil {
+			return parts
+		}
+		trailing = true
+	}
+
+	if len(choices) < minSuggestions || len(choices) > maxSuggestions || (trailing && !quoted) {
+		return nil
+	}
+	slices.Reverse(choices)
+	return choices
+}
+
+var bulletPrefixes = []string{"- ", "• ", "* ", "— ", "– "}
+
+var quotePairs = [][2]string{{"«", "»"}, {"\"", "\""}, {"“", "”"}, {"**", "**"}}
+
+// choiceLine extracts an option from a bulleted, numbered or quoted line and
+// reports whether it was quoted.
+func choiceLine(line string) (choice string, quoted, ok bool) {
+	s, marked := line, false
+	for _, p := range bulletPrefixes {
+		if strings.HasPrefix(s, p) {
+			s, marked = s[len(p):], true
+			break
+		}
+	}
+	if !marked {
+		if n := numberPrefix(s); n > 0 {
+			s, marked = s[n:], true
+		}
+	}
+
+	s = strings.TrimRight(strings.TrimSpace(s), ";,.")
+	for _, q := range quotePairs {
+		if len(s) > len(q[0])+len(q[1]) && strings.HasPrefix(s, q[0]) && strings.HasSuffix(s, q[1]) {
+			s, quoted = strings.TrimSpace(s[len(q[0]):len(s)-len(q[1])]), true
+		}
+	}
+
+	if !marked && !quoted {
+		return "", false, false
+	}
+	if s == "" || strings.HasSuffix(s, "?") || strings.HasSuffix(s, ":") || utf8.RuneCountInString(s) > maxSuggestionLen {
+		return "", false, false
+	}
+	return s, quoted, true
+}
+
+// numberPrefix returns the length of a "1. " or "2) " list marker.
+func numberPrefix(s string) int {
+	i := 0
+	for i < len(s) && i < 2 && unicode.IsDigit(rune(s[i])) {
+		i++
+	}
+	if i == 0 || i+1 >= len(s) || (s[i] != '.' && s[i] != ')') || s[i+1] != ' ' {
+		return 0
+	}
+	return i + 2
+}
+
+func slashChoices(line string) []string {
+	if strings.HasSuffix(line, "?") {
+		return nil
+	}
+	parts := strings.Split(line, " / ")
+	if len(parts) < minSuggestions || len(parts) > maxSuggestions {
+		return nil
+	}
+	for i, p := range parts {
+		p = strings.Trim(strings.TrimSpace(p), "«»\"“”.;,")
+		if p == "" || utf8.RuneCountInString(p) > maxSuggestionLen {
+			return nil
+		}
+		parts[i] = p
+	}
+	return parts
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseChoices(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name: "quoted options before the closing question, as in the prompt",
+			content: "Предлагаю начать с подушки.\n\n«Проверить лимит сейчас»\n\n«Открыть “Копилку” и внести 100 000 ₸»\n\n" +
+				"«Посчитать помесячные платежи»\n\nС чего начнём? Я рядом, подскажу на каждом шаге.",
+			want: []string{"Проверить лимит сейчас", "Открыть “Копилку” и внести 100 000 ₸", "Посчитать помесячные платежи"},
+		},
+		{
+			name:    "bulleted quoted options before a question",
+			content: "Что дальше?\n- «Открыть Копилку»\n- «Посчитать график»\nВыбирайте!",
+			want:    []string{"Открыть Копилку", "Посчитать график"},
+		},
+		{
+			name:    "bulleted list ending the reply",
+			content: "Могу помочь так:\n- Открыть Копилку\n- Посчитать график",
+			want:    []string{"Открыть Копилку", "Посчитать график"},
+		},
+		{
+			name:    "numbered list ending the reply",
+			content: "Варианты:\n1. Копилка\n2) Вакала;",
+			want:    []string{"Копилка", "Вакала"},
+		},
+		{
+			name:    "slash options",
+			content: "Как продолжим?\n«Копилка» / «Вакала» / Обе",
+			want:    []string{"Копилка", "Вакала", "Обе"},
+		},
+		{
+			name:    "numbered steps followed by a question",
+			content: "План:\n1. Откройте Копилку\n2. Настройте автоперевод\nКак вам такой старт?",
+		},
+		{
+			name: "dashed steps followed by a question",
+			content: "Микро-шаги:\n— открыть «Копилку» и внести 100 000 ₸;\n— поставить автоперевод 25 000 ₸ по понедельникам;\n" +
+				"— через месяц посмотрим прогресс.\nКак вам такой старт?",
+		},
+		{
+			name:    "a single option",
+			content: "Готовы?\n- Да",
+		},
+		{
+			name:    "too many options",
+			content: "- a\n- b\n- c\n- d",
+		},
+		{
+			name:    "long lines are prose",
+			content: "- " + strings.Repeat("слово ", 20) + "\n- коротко",
+		},
+		{
+			name:    "questions are not options",
+			content: "- Хотите открыть Копилку?\n- Посчитать график?",
+		},
+		{
+			name:    "plain prose",
+			content: "Подушка — это 3–6 месяцев расходов.",
+		},
+	}
+	for _, tt := range tests {
+		if got := parseChoices(tt.content); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: parseChoices = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"backend/llm"
+	"backend/models"
+
+	"github.com/google/uuid"
+)
+
+const summaryTimeout = 2 * time.Minute
+
+const summaryPreamble = "Краткое содержание предыдущей части разговора (исходные сообщения опущены):\n"
+
+const summaryPrompt = `Ты ведёшь память финансового коуча Zaman. Сожми переписку с клиентом в короткую заметку для себя.
+Сохрани: цели клиента, его финансовые данные (доход, расходы, суммы, сроки, возраст, сегмент), принятые решения, уже предложенные продукты и открытые вопросы.
+Не добавляй ничего, чего не было в переписке. Пиши по-русски, списком, не более 200 слов.`
+
+// maybeSummarize folds older turns of the chat into its rolling summary in
+// the background. At most one summary per chat is generated at a time.
+func (s *service) maybeSummarize(chatID uuid.UUID) {
+	if !s.cfg.LLM.SummaryEnabled {
+		return
+	}
+	if _, busy := s.summarizing.LoadOrStore(chatID, struct{}{}); busy {
+		return
+	}
+
+	go func() {
+		defer s.summarizing.Delete(chatID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), summaryTimeout)
+		defer cancel()
+
+		if err := s.summarize(ctx, chatID); err != nil {
+			log.Printf("Chat %s: summary failed: %v", chatID, err)
+		}
+	}()
+}
+
+func (s *service) summarize(ctx context.Context, chatID uuid.UUID) error {
+	messages, err := s.repo.ListBranch(ctx, chatID)
+	if err != nil {
+		return err
+	}
+
+	prev, err := s.repo.GetLatestSummary(ctx, chatID)
+	if errors.Is(err, models.ErrNotFound) || (prev != nil && (prev.Stale || !summaryFits(prev, messages))) {
+		// Start over when a covered message was removed or the chat moved
+		// to another branch.
+		prev, err = nil, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	covered := map[uuid.UUID]bool{}
+	if prev != nil {
+		for _, id := range prev.MessageIDs {
+			covered[id] = true
+		}
+	}
+
+	var pending []models.Message
+	for _, m := range messages {
+		if m.Role == "system" || covered[m.ID] {
+			continue
+		}
+		pending = append(pending, m)
+	}
+
+	cut := len(pending) - s.cfg.LLM.SummaryKeepRecent
+	// Never split a question from its answer.
+	for cut > 0 && (pending[cut-1].Role != "assistant" || pending[cut-1].IsToolStep()) {
+		cut--
+	}
+	if cut < s.cfg.LLM.SummaryBatch {
+		return nil
+	}
+	batch := pending[:cut]
+
+	var transcript strings.Builder
+	if prev != nil {
+		transcript.WriteString("Предыдущая заметка:\n")
+		transcript.WriteString(prev.Content)
+		transcript.WriteString("\n\nНовые сообщения:\n")
+	}
+	for _, m := range batch {
+		if m.IsToolStep() || m.Unfinished() {
+			continue
+		}
+		fmt.Fprintf(&transcript, "%s: %s\n\n", m.Role, messageText(&m))
+	}
+
+	resp, err := s.llm.ChatCompletion(ctx, s.newChatRequest(s.cfg.LLM.DefaultModel, []llm.Message{
+		{Role: "system", Content: summaryPrompt},
+		{Role: "user", Content: transcript.String()},
+	}))
+	if err != nil {
+		return err
+	}
+
+	summary := &models.ChatSummary{
+		ChatID:  chatID,
+		Content: strings.TrimSpace(resp.Message.Content),
+		Model:   resp.Model,
+	}
+	if prev != nil {
+		summary.MessageIDs = append(summary.MessageIDs, prev.MessageIDs...)
+	}
+	for _, m := range batch {
+		summary.MessageIDs = append(summary.MessageIDs, m.ID)
+	}
+
+	return s.repo.SaveSummary(ctx, summary)
+}
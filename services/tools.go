@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"backend/llm"
+	"backend/models"
+)
+
+// ErrToolLoop is returned when the model keeps calling tools after it was
+// told to answer.
+var ErrToolLoop = errors.New("llm did not produce an answer within the tool call limit")
+
+// toolFunc executes one tool call. args is the raw JSON the model produced;
+// the result is marshalled back to the model as the tool message.
+type toolFunc func(ctx context.Context, chat *models.Chat, args json.RawMessage) (any, error)
+
+type toolset struct {
+	decls []llm.Tool
+	funcs map[string]toolFunc
+}
+
+func (t *toolset) add(decl llm.Tool, fn toolFunc) {
+	if t.funcs == nil {
+		t.funcs = make(map[string]toolFunc)
+	}
+	t.decls = append(t.decls, decl)
+	t.funcs[decl.Function.Name] = fn
+}
+
+// newToolset declares the backend capabilities the model may call.
+func (s *service) newToolset() *toolset {
+	t := &toolset{}
+	t.add(llm.NewTool("check_eligibility",
+		"Проверяет, какие продукты Zaman доступны клиенту по возрасту, сумме, сроку, залогу и цели. "+
+			"Используй перед тем, как рекомендовать продукт.",
+		`{
+	"type": "object",
+	"properties": {
+		"segment": {"type": "string", "enum": ["retail", "business"]},
+		"age": {"type": "integer"},
+		"amount": {"type": "integer", "description": "Сумма в тенге"},
+		"term": {"type": "integer"},
+		"term_unit": {"type": "string", "enum": ["month", "day"]},
+		"has_collateral": {"type": "boolean"},
+		"purpose": {"type": "string", "enum": ["purchase", "housing", "cushion", "savings", "liquidity", "working_capital", "equipment", "investment", "placement", "payments"]}
+	},
+	"required": ["segment"]
+}`), s.eligibilityTool)
+	t.add(llm.NewTool("compute_savings_cushion",
+		"Рассчитывает финансовую подушку (3–6 месяцев расходов) и её разбивку «Копилка»/«Вакала».",
+		`{
+	"type": "object",
+	"properties": {
+		"monthly_expenses": {"type": "integer", "description": "Среднемесячные расходы в тенге"},
+		"months": {"type": "integer", "description": "На сколько месяцев расходов копить, по умолчанию 6"},
+		"current_savings": {"type": "integer", "description": "Уже накоплено, тенге"},
+		"monthly_contribution": {"type": "integer", "description": "Сколько клиент готов откладывать в месяц, тенге"}
+	},
+	"required": ["monthly_expenses"]
+}`), s.cushionTool)
+	t.add(llm.NewTool("compute_installment_schedule",
+		"Рассчитывает график платежей: bnpl — рассрочка равными частями без наценки, "+
+			"murabaha — исламское финансирование с фиксированной наценкой по договору, "+
+			"mortgage — исламская ипотека с первоначальным взносом. Называй клиенту только эти цифры.",
+		`{
+	"type": "object",
+	"properties": {
+		"kind": {"type": "string", "enum": ["bnpl", "murabaha", "mortgage"]},
+		"amount": {"type": "integer", "description": "Стоимость покупки или жилья в тенге"},
+		"term": {"type": "integer", "description": "Срок в месяцах"},
+		"markup_rate": {"type": "number", "description": "Наценка по договору, % годовых; для bnpl не нужна"},
+		"down_payment": {"type": "integer", "description": "Первоначальный взнос в тенге, только для mortgage"}
+	},
+	"required": ["kind", "amount", "term"]
+}`), s.scheduleTool)
+	t.add(llm.NewTool("get_goals",
+		"Возвращает цели клиента с прогрессом и микро-шагами из приложения.",
+		`{
+	"type": "object",
+	"properties": {
+		"status": {"type": "string", "enum": ["active", "achieved", "archived"], "description": "По умолчанию active"}
+	}
+}`), s.goalsTool)
+	return t
+}
+
+// run executes call and returns the JSON sent back to the model. Failures are
+// reported to the model rather than failing the turn, so it can ask the
+// client for what is missing.
+func (t *toolset) run(ctx context.Context, chat *models.Chat, call llm.ToolCall) string {
+	fn, ok := t.funcs[call.Function.Name]
+	if !ok {
+		return toolError(fmt.Errorf("unknown tool %q", call.Function.Name))
+	}
+
+	result, err := fn(ctx, chat, json.RawMessage(call.Function.Arguments))
+	if err != nil {
+		log.Printf("Chat %s: tool %s failed: %v", chat.ID, call.Function.Name, err)
+		return toolError(err)
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return toolError(err)
+	}
+	return string(data)
+}
+
+func toolError(err error) string {
+	data, _ := json.Marshal(map[string]string{"error": err.Error()})
+	return string(data)
+}
+
+// decodeToolArgs unmarshals and validates the model's arguments.
+func (s *service) decodeToolArgs(args json.RawMessage, v any) error {
+	if err := json.Unmarshal(args, v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return s.validate.Validate(v)
+}
+
+// completionFunc is either ChatCompletion or a streaming call bound to its
+// delta callback.
+type completionFunc func(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error)
+
+// completeWithTools sends req with the toolset attached and executes the
+// model's tool calls until it answers in text. It returns the tool calls and
+// results as messages of chat, in order, followed by the final response. The
+// last allowed round forbids tool use so a turn always ends. Models without
+// function calling get a single plain request.
+func (s *service) completeWithTools(ctx context.Context, req *llm.ChatRequest, chat *models.Chat, call completionFunc) ([]models.Message, *llm.ChatResponse, error) {
+	if spec, ok := s.cfg.LLM.Model(req.Model); !ok || !spec.Tools {
+		resp, err := call(ctx, req)
+		return nil, resp, err
+	}
+	req.Tools = s.tools.decls
+
+	var steps []models.Message
+	for round := 1; ; round++ {
+		req.ToolChoice = llm.ToolChoiceAuto
+		if round >= s.cfg.LLM.MaxToolIterations {
+			req.ToolChoice = llm.ToolChoiceNone
+		}
+
+		resp, err := call(ctx, req)
+		if err != nil || len(resp.Message.ToolCalls) == 0 {
+			return steps, resp, err
+		}
+		if req.ToolChoice == llm.ToolChoiceNone {
+			return steps, nil, ErrToolLoop
+		}
+
+		req.Messages = append(req.Messages, resp.Message)
+		step := models.Message{ChatID: chat.ID, Role: "assistant", Content: resp.Message.Content, Model: resp.Model}
+		for _, tc := range resp.Message.ToolCalls {
+			step.ToolCalls = append(step.ToolCalls, models.ToolCall{
+				ID:        tc.ID,
+				Name:      tc.Function.Name,
+				Arguments: tc.Function.Arguments,
+			})
+		}
+		steps = append(steps, step)
+
+		for _, tc := range resp.Message.ToolCalls {
+			result := s.tools.run(ctx, chat, tc)
+			req.Messages = append(req.Messages, llm.Message{Role: "tool", Content: result, ToolCallID: tc.ID})
+			steps = append(steps, models.Message{ChatID: chat.ID, Role: "tool", Content: result, ToolCallID: tc.ID})
+		}
+	}
+}
+
+// eligibilityToolResult trims the eligibility result to what the model needs
+// to explain it: no full product records, and only failed rules for rejected
+// products.
+type eligibilityToolResult struct {
+	Eligible           []eligibilityToolProduct `json:"eligible"`
+	Rejected           []eligibilityToolProduct `json:"rejected"`
+	NearestAlternative *eligibilityToolProduct  `json:"nearest_alternative,omitempty"`
+}
+
+type eligibilityToolProduct struct {
+	Code    string   `json:"code"`
+	Name    string   `json:"name"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+func (s *service) eligibilityTool(ctx context.Context, _ *models.Chat, args json.RawMessage) (any, error) {
+	var req models.EligibilityRequest
+	if err := s.decodeToolArgs(args, &req); err != nil {
+		return nil, err
+	}
+	result, err := s.CheckEligibility(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	brief := func(m models.ProductMatch, failedOnly bool) eligibilityToolProduct {
+		p := eligibilityToolProduct{Code: m.Product.Code, Name: m.Product.Name}
+		for _, c := range m.Checks {
+			if !failedOnly || !c.Passed {
+				p.Reasons = append(p.Reasons, c.Reason)
+			}
+		}
+		return p
+	}
+
+	out := eligibilityToolResult{
+		Eligible: make([]eligibilityToolProduct, 0, len(result.Eligible)),
+		Rejected: make([]eligibilityToolProduct, 0, len(result.Rejected)),
+	}
+	for _, m := range result.Eligible {
+		out.Eligible = append(out.Eligible, brief(m, false))
+	}
+	for _, m := range result.Rejected {
+		out.Rejected = append(out.Rejected, brief(m, true))
+	}
+	if result.NearestAlternative != nil {
+		alt := brief(*result.NearestAlternative, true)
+		out.NearestAlternative = &alt
+	}
+	return out, nil
+}
+
+func (s *service) cushionTool(ctx context.Context, _ *models.Chat, args json.RawMessage) (any, error) {
+	var req models.CushionRequest
+	if err := s.decodeToolArgs(args, &req); err != nil {
+		return nil, err
+	}
+	return s.CalculateCushion(ctx, &req)
+}
+
+// scheduleToolPeriods caps the periods sent to the model: the first months
+// and the final one. Long mortgages would otherwise cost thousands of tokens
+// for numbers it does not need; the totals still cover the whole term.
+const scheduleToolPeriods = 12
+
+func (s *service) scheduleTool(ctx context.Context, _ *models.Chat, args json.RawMessage) (any, error) {
+	var req models.ScheduleRequest
+	if err := s.decodeToolArgs(args, &req); err != nil {
+		return nil, err
+	}
+	schedule, err := s.CalculateSchedule(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+	if len(schedule.Periods) > scheduleToolPeriods {
+		last := schedule.Periods[len(schedule.Periods)-1]
+		schedule.Periods = append(schedule.Periods[:scheduleToolPeriods-1], last)
+	}
+	return schedule, nil
+}
+
+func (s *service) goalsTool(ctx context.Context, chat *models.Chat, args json.RawMessage) (any, error) {
+	var req struct {
+		Status string `json:"status" validate:"omitempty,oneof=active achieved archived"`
+	}
+	if len(args) > 0 {
+		if err := s.decodeToolArgs(args, &req); err != nil {
+			return nil, err
+		}
+	}
+	if req.Status == "" {
+		req.Status = models.GoalStatusActive
+	}
+	return s.ListGoals(ctx, chat.UserID, req.Status)
+}
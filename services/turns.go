@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"backend/llm"
+	"backend/models"
+	"backend/repositories"
+
+	"github.com/google/uuid"
+)
+
+var ErrNotRetryable = errors.New("only a failed reply at the end of a chat can be retried")
+
+// stalePendingAfter is how long a reply may stay pending before it counts as
+// abandoned, e.g. by a process that crashed mid-turn, and may be retried. It
+// is well beyond what a turn takes, streamed ones included.
+const stalePendingAfter = 10 * time.Minute
+
+// continueBranch places a new request at the end of the branch the client
+// saw: the chat's active one, as history was loaded.
+func continueBranch(request *models.Message, chat *models.Chat) {
+	if request.ID == uuid.Nil && request.ParentID == nil {
+		request.ParentID = chat.ActiveLeafID
+	}
+}
+
+// saveTurn stores a turn in order, each message below the one before: the
+// request unless it is already saved, the tool steps, then the reply.
+func saveTurn(ctx context.Context, repo repositories.Repository, request *models.Message, steps []models.Message, reply *models.Message) error {
+	if request.ID == uuid.Nil {
+		if err := repo.SaveMessage(ctx, request); err != nil {
+			return err
+		}
+	}
+	parent := &request.ID
+	for i := range steps {
+		steps[i].ParentID = parent
+		if err := repo.SaveMessage(ctx, &steps[i]); err != nil {
+			return err
+		}
+		parent = &steps[i].ID
+	}
+	reply.ParentID = parent
+	return repo.SaveMessage(ctx, reply)
+}
+
+// startTurn stores the user message, unless it is already saved, together
+// with a pending assistant message for finishTurn or failTurn to settle.
+func (s *service) startTurn(ctx context.Context, request *models.Message) (*models.Message, error) {
+	pending := &models.Message{ChatID: request.ChatID, Role: "assistant", Status: models.MessageStatusPending}
+	err := s.repo.WithTx(ctx, func(repo repositories.Repository) error {
+		if request.ID == uuid.Nil {
+			if err := repo.SaveMessage(ctx, request); err != nil {
+				return err
+			}
+		}
+		pending.ParentID = &request.ID
+		return repo.SaveMessage(ctx, pending)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
+
+// runTurn answers request from history and settles the pending reply.
+func (s *service) runTurn(ctx context.Context, pending, request *models.Message, history *models.Chat, opts models.ReplyOptions) (*models.Message, error) {
+	reply, steps, err := s.LLMRequest(ctx, request, history, opts)
+	if err != nil {
+		s.failTurn(ctx, pending, err)
+		return nil, err
+	}
+	if err := s.finishTurn(ctx, pending, steps, reply); err != nil {
+		return nil, err
+	}
+	s.maybeSummarize(history.ID)
+	return reply, nil
+}
+
+// finishTurn stores the tool steps and turns the pending message into reply,
+// chaining them below the question the pending message answers.
+func (s *service) finishTurn(ctx context.Context, pending *models.Message, steps []models.Message, reply *models.Message) error {
+	reply.ID = pending.ID
+	reply.ChatID = pending.ChatID
+	if reply.Status == "" {
+		reply.Status = models.MessageStatusCompleted
+	}
+	return s.repo.WithTx(ctx, func(repo repositories.Repository) error {
+		parent := pending.ParentID
+		for i := range steps {
+			steps[i].ParentID = parent
+			if err := repo.SaveMessage(ctx, &steps[i]); err != nil {
+				return err
+			}
+			parent = &steps[i].ID
+		}
+		reply.ParentID = parent
+		return repo.FinalizeMessage(ctx, reply)
+	})
+}
+
+// failTurn settles the pending message as failed, or cancelled if the client
+// went away, recording why.
+func (s *service) failTurn(ctx context.Context, pending *models.Message, cause error) {
+	pending.Status = models.MessageStatusFailed
+	if ctx.Err() != nil {
+		pending.Status = models.MessageStatusCancelled
+	}
+	pending.Error = failureCode(cause)
+	log.Printf("Chat %s: reply %s %s: %v", pending.ChatID, pending.ID, pending.Status, cause)
+
+	if err := s.repo.FinalizeMessage(context.WithoutCancel(ctx), pending); err != nil {
+		log.Printf("Chat %s: settling reply %s: %v", pending.ChatID, pending.ID, err)
+	}
+}
+
+// failureCode is the stable code stored with a failed reply.
+func failureCode(err error) string {
+	switch code := llm.ErrorCode(err); {
+	case code != "":
+		return code
+	case errors.Is(err, context.Canceled):
+		return "cancelled"
+	case errors.Is(err, llm.ErrContextTooLong):
+		return "context_too_long"
+	case errors.Is(err, ErrToolLoop):
+		return "tool_loop"
+	default:
+		return "internal_error"
+	}
+}
+
+// RetryMessage generates a failed reply again, answering the same user
+// message. A reply left pending for longer than stalePendingAfter is treated
+// as failed. Only the last message of the active branch can be retried: a new
+// answer in the middle would not fit the turns that followed.
+func (s *service) RetryMessage(ctx context.Context, userID, chatID, messageID uuid.UUID, opts models.ReplyOptions) (*models.Message, error) {
+	chat, err := s.GetChatByID(ctx, userID, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs := chat.Messages
+	last := len(msgs) - 1
+	if last < 0 || msgs[last].ID != messageID {
+		for _, m := range msgs {
+			if m.ID == messageID {
+				return nil, ErrNotRetryable
+			}
+		}
+		return nil, models.ErrNotFound
+	}
+	if msgs[last].Role != "assistant" || !retryable(&msgs[last]) {
+		return nil, ErrNotRetryable
+	}
+	question := last - 1
+	for question >= 0 && msgs[question].Role != "user" {
+		question--
+	}
+	if question < 0 {
+		return nil, ErrNotRetryable
+	}
+
+	// Tool steps of the failed attempt go; the new attempt makes its own.
+	var stale []uuid.UUID
+	for _, m := range msgs[question+1 : last] {
+		stale = append(stale, m.ID)
+	}
+	err = s.repo.WithTx(ctx, func(repo repositories.Repository) error {
+		var err error
+		if msgs[last].Status == models.MessageStatusPending {
+			err = repo.ReclaimPendingMessage(ctx, messageID, time.Now().Add(-stalePendingAfter))
+		} else {
+			err = repo.TransitionMessageStatus(ctx, messageID, models.MessageStatusFailed, models.MessageStatusPending)
+		}
+		if errors.Is(err, models.ErrNotFound) {
+			return ErrNotRetryable // retried concurrently
+		}
+		if err != nil {
+			return err
+		}
+		return repo.DeleteMessages(ctx, stale)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pending := &models.Message{ID: messageID, ChatID: chatID, Role: "assistant", Status: models.MessageStatusPending,
+		ParentID: &msgs[question].ID}
+	history := *chat
+	history.Messages = msgs[:question]
+	return s.runTurn(ctx, pending, &msgs[question], &history, opts)
+}
+
+// retryable reports whether reply failed or was abandoned while pending.
+func retryable(reply *models.Message) bool {
+	switch reply.Status {
+	case models.MessageStatusFailed:
+		return true
+	case models.MessageStatusPending:
+		return time.Since(reply.CreatedAt) > stalePendingAfter
+	}
+	return false
+}